@@ -0,0 +1,106 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// An abstract is either a single block of text, or for structured abstracts a series of labelled
+// sections, such as BACKGROUND, METHODS, RESULTS, and CONCLUSIONS.
+type Abstract struct {
+	XMLName              xml.Name          `xml:"Abstract"`
+	Sections             []AbstractSection `xml:"AbstractText"`
+	CopyrightInformation string            `xml:"CopyrightInformation"`
+}
+
+// Label is the heading as given by the publisher, and NlmCategory is NLM's mapping of that on to one
+// of BACKGROUND, OBJECTIVE, METHODS, RESULTS, CONCLUSIONS, or UNASSIGNED.
+type AbstractSection struct {
+	Label       string
+	NlmCategory string
+	Text        string
+}
+
+// AbstractText can contain markup such as <i>, <sup>, and MathML. We don't want any of that, just
+// the words, so we pull out all the character data and tidy up the whitespace.
+func (s *AbstractSection) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "Label":
+			s.Label = attr.Value
+		case "NlmCategory":
+			s.NlmCategory = attr.Value
+		}
+	}
+
+	var text strings.Builder
+	depth := 1
+	for depth > 0 {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth += 1
+		case xml.EndElement:
+			depth -= 1
+		case xml.CharData:
+			text.Write(t)
+		}
+	}
+
+	s.Text = strings.Join(strings.Fields(text.String()), " ")
+	return nil
+}
+
+func (article PubmedArticle) GetAbstract() Abstract {
+
+	if len(article.MedlineCitation.Article) == 0 {
+		return Abstract{}
+	}
+	return article.MedlineCitation.Article[0].Abstract
+}
+
+func (abstract Abstract) IsStructured() bool {
+
+	for _, section := range abstract.Sections {
+		if section.Label != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the abstract as a single line of text, with the label of each section, if it has one,
+// in front of it, e.g., "BACKGROUND: Some words. METHODS: Some more words."
+func (abstract Abstract) GetText() string {
+
+	parts := make([]string, 0, len(abstract.Sections))
+	for _, section := range abstract.Sections {
+		if section.Text == "" {
+			continue
+		}
+		if section.Label != "" {
+			parts = append(parts, section.Label+": "+section.Text)
+		} else {
+			parts = append(parts, section.Text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,87 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestUnstructuredAbstract(t *testing.T) {
+	article_set, err := loadXML("testdata/example1.xml")
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+
+	abstract := article_set.Articles[0].GetAbstract()
+	if len(abstract.Sections) != 1 {
+		t.Fatalf("Expected one abstract section, got %d", len(abstract.Sections))
+	}
+	if abstract.IsStructured() {
+		t.Errorf("Expected abstract to not be structured")
+	}
+	if !strings.HasPrefix(abstract.GetText(), "Leptospirosis is an acute bacterial septicemic febrile disease") {
+		t.Errorf("Unexpected abstract text: %s", abstract.GetText())
+	}
+}
+
+func TestStructuredAbstract(t *testing.T) {
+
+	data := `<Abstract>
+		<AbstractText Label="BACKGROUND" NlmCategory="BACKGROUND">Leptospirosis is a <i>zoonosis</i>.</AbstractText>
+		<AbstractText Label="METHODS" NlmCategory="METHODS">We measured CO<sub>2</sub> at
+			10<sup>3</sup> sites.</AbstractText>
+		<AbstractText Label="FINDINGS" NlmCategory="RESULTS">Rates rose.</AbstractText>
+		<CopyrightInformation>Copyright © 2019 The Authors.</CopyrightInformation>
+	</Abstract>`
+
+	abstract := Abstract{}
+	err := xml.Unmarshal([]byte(data), &abstract)
+	if err != nil {
+		t.Fatalf("Failed to parse test data: %v", err)
+	}
+
+	testdata := []struct {
+		Label       string
+		NlmCategory string
+		Text        string
+	}{
+		{Label: "BACKGROUND", NlmCategory: "BACKGROUND", Text: "Leptospirosis is a zoonosis."},
+		{Label: "METHODS", NlmCategory: "METHODS", Text: "We measured CO2 at 103 sites."},
+		{Label: "FINDINGS", NlmCategory: "RESULTS", Text: "Rates rose."},
+	}
+
+	if len(abstract.Sections) != len(testdata) {
+		t.Fatalf("Expected %d sections, got %d", len(testdata), len(abstract.Sections))
+	}
+	for idx, section := range testdata {
+		if abstract.Sections[idx] != (AbstractSection{Label: section.Label, NlmCategory: section.NlmCategory, Text: section.Text}) {
+			t.Errorf("Section %d: unexpected %v", idx, abstract.Sections[idx])
+		}
+	}
+
+	if !abstract.IsStructured() {
+		t.Errorf("Expected abstract to be structured")
+	}
+	if abstract.CopyrightInformation != "Copyright © 2019 The Authors." {
+		t.Errorf("Unexpected copyright: %s", abstract.CopyrightInformation)
+	}
+
+	expected := "BACKGROUND: Leptospirosis is a zoonosis. METHODS: We measured CO2 at 103 sites. FINDINGS: Rates rose."
+	if abstract.GetText() != expected {
+		t.Errorf("Unexpected abstract text: %s", abstract.GetText())
+	}
+}
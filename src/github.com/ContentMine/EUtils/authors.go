@@ -0,0 +1,161 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+type AuthorList struct {
+	XMLName    xml.Name `xml:"AuthorList"`
+	CompleteYN string   `xml:"CompleteYN,attr"`
+	Authors    []Author `xml:"Author"`
+}
+
+// An author is either a person, with a LastName and usually a ForeName, or a group, in which case
+// only CollectiveName is set.
+type Author struct {
+	XMLName         xml.Name          `xml:"Author"`
+	ValidYN         string            `xml:"ValidYN,attr"`
+	EqualContrib    string            `xml:"EqualContrib,attr"`
+	LastName        string            `xml:"LastName"`
+	ForeName        string            `xml:"ForeName"`
+	Initials        string            `xml:"Initials"`
+	Suffix          string            `xml:"Suffix"`
+	CollectiveName  string            `xml:"CollectiveName"`
+	Identifiers     []Identifier      `xml:"Identifier"`
+	AffiliationInfo []AffiliationInfo `xml:"AffiliationInfo"`
+}
+
+type Identifier struct {
+	XMLName xml.Name `xml:"Identifier"`
+	Source  string   `xml:"Source,attr"`
+	Value   string   `xml:",chardata"`
+}
+
+type AffiliationInfo struct {
+	XMLName     xml.Name     `xml:"AffiliationInfo"`
+	Affiliation string       `xml:"Affiliation"`
+	Identifiers []Identifier `xml:"Identifier"`
+}
+
+const ORCID_IDENTIFIER_SOURCE = "ORCID"
+
+// Returns the authors of the article in the order they are credited, leaving out any that NCBI
+// have marked as invalid (these are kept in the record when a name is corrected, alongside the
+// corrected version).
+func (article PubmedArticle) GetAuthors() []Author {
+
+	authors := make([]Author, 0)
+	if len(article.MedlineCitation.Article) == 0 {
+		return authors
+	}
+	for _, author := range article.MedlineCitation.Article[0].AuthorList.Authors {
+		if author.ValidYN != "N" {
+			authors = append(authors, author)
+		}
+	}
+	return authors
+}
+
+func (author Author) IsCollective() bool {
+	return author.CollectiveName != "" && author.LastName == ""
+}
+
+func (author Author) IsEqualContrib() bool {
+	return author.EqualContrib == "Y"
+}
+
+// Returns the author's name as it would be written in a byline, e.g., "Ana Maria Silva Jr", or
+// the group name for a collective author.
+func (author Author) GetName() string {
+
+	if author.IsCollective() {
+		return strings.TrimSpace(author.CollectiveName)
+	}
+
+	parts := make([]string, 0, 3)
+	for _, part := range []string{author.ForeName, author.LastName, author.Suffix} {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func (author Author) GetAffiliations() []string {
+
+	affiliations := make([]string, 0, len(author.AffiliationInfo))
+	for _, info := range author.AffiliationInfo {
+		affiliation := strings.TrimSpace(info.Affiliation)
+		if affiliation != "" {
+			affiliations = append(affiliations, affiliation)
+		}
+	}
+	return affiliations
+}
+
+// Returns the author's ORCID in the canonical 0000-0000-0000-0000 form, or an empty string if
+// they don't have one or the one given isn't valid.
+func (author Author) GetORCID() string {
+
+	for _, identifier := range author.Identifiers {
+		if identifier.Source == ORCID_IDENTIFIER_SOURCE {
+			orcid, err := NormaliseORCID(identifier.Value)
+			if err == nil {
+				return orcid
+			}
+		}
+	}
+	return ""
+}
+
+// PubMed records have ORCIDs in a variety of forms: as a URL, with or without the dashes, and
+// occasionally with stray whitespace. This returns them in the form Wikidata uses, checking
+// the checksum digit along the way.
+func NormaliseORCID(value string) (string, error) {
+
+	orcid := strings.TrimSpace(value)
+	if idx := strings.LastIndex(orcid, "/"); idx != -1 {
+		orcid = orcid[idx+1:]
+	}
+	orcid = strings.ToUpper(strings.Replace(strings.Replace(orcid, "-", "", -1), " ", "", -1))
+
+	if len(orcid) != 16 {
+		return "", fmt.Errorf("ORCID %s is the wrong length", value)
+	}
+
+	// ISO 7064 11,2 checksum over the first 15 digits
+	total := 0
+	for _, c := range orcid[:15] {
+		if c < '0' || c > '9' {
+			return "", fmt.Errorf("ORCID %s contains invalid characters", value)
+		}
+		total = (total + int(c-'0')) * 2
+	}
+	check := (12 - total%11) % 11
+	expected := byte('0' + check)
+	if check == 10 {
+		expected = 'X'
+	}
+	if orcid[15] != expected {
+		return "", fmt.Errorf("ORCID %s has an invalid checksum", value)
+	}
+
+	return fmt.Sprintf("%s-%s-%s-%s", orcid[0:4], orcid[4:8], orcid[8:12], orcid[12:16]), nil
+}
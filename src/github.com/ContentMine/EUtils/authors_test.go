@@ -0,0 +1,109 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"testing"
+)
+
+func TestGetAuthors(t *testing.T) {
+	article_set, err := loadXML("testdata/authors.xml")
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+
+	authors := article_set.Articles[0].GetAuthors()
+
+	testdata := []struct {
+		Name         string
+		ORCID        string
+		Collective   bool
+		EqualContrib bool
+		Affiliations int
+	}{
+		{Name: "Josiah S Carberry", ORCID: "0000-0002-1825-0097", EqualContrib: true, Affiliations: 2},
+		{Name: "John Smith Jr", ORCID: "0000-0002-1694-233X", EqualContrib: true},
+		{Name: "Mary Jones", ORCID: ""},
+		{Name: "Test Study Group", Collective: true},
+	}
+
+	if len(authors) != len(testdata) {
+		t.Fatalf("Expected %d authors, got %d", len(testdata), len(authors))
+	}
+
+	for idx, data := range testdata {
+		author := authors[idx]
+		if author.GetName() != data.Name {
+			t.Errorf("Author %d: expected name %s, got %s", idx, data.Name, author.GetName())
+		}
+		if author.GetORCID() != data.ORCID {
+			t.Errorf("Author %d: expected ORCID %s, got %s", idx, data.ORCID, author.GetORCID())
+		}
+		if author.IsCollective() != data.Collective {
+			t.Errorf("Author %d: expected collective %v", idx, data.Collective)
+		}
+		if author.IsEqualContrib() != data.EqualContrib {
+			t.Errorf("Author %d: expected equal contrib %v", idx, data.EqualContrib)
+		}
+		if len(author.GetAffiliations()) != data.Affiliations {
+			t.Errorf("Author %d: expected %d affiliations, got %d", idx, data.Affiliations, len(author.GetAffiliations()))
+		}
+	}
+}
+
+func TestGenericExampleAuthors(t *testing.T) {
+	article_set, err := loadXML("testdata/example1.xml")
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+
+	authors := article_set.Articles[0].GetAuthors()
+	if len(authors) != 3 {
+		t.Fatalf("Expected 3 authors, got %d", len(authors))
+	}
+	if authors[1].GetName() != "Ana Maria Gonçalves da Silva" {
+		t.Errorf("Unexpected name for second author: %s", authors[1].GetName())
+	}
+}
+
+func TestNormaliseORCID(t *testing.T) {
+
+	testdata := []struct {
+		Value string
+		ORCID string
+		Valid bool
+	}{
+		{Value: "0000-0002-1825-0097", ORCID: "0000-0002-1825-0097", Valid: true},
+		{Value: "http://orcid.org/0000-0001-5109-3700", ORCID: "0000-0001-5109-3700", Valid: true},
+		{Value: " 0000000218250097 ", ORCID: "0000-0002-1825-0097", Valid: true},
+		{Value: "0000-0002-1694-233x", ORCID: "0000-0002-1694-233X", Valid: true},
+		{Value: "0000-0002-1825-0098", Valid: false},
+		{Value: "0000-0002-1825", Valid: false},
+		{Value: "000A-0002-1825-0097", Valid: false},
+	}
+
+	for _, data := range testdata {
+		orcid, err := NormaliseORCID(data.Value)
+		if data.Valid {
+			if err != nil {
+				t.Errorf("Expected %s to be valid: %v", data.Value, err)
+			} else if orcid != data.ORCID {
+				t.Errorf("Expected %s to normalise to %s, got %s", data.Value, data.ORCID, orcid)
+			}
+		} else if err == nil {
+			t.Errorf("Expected %s to be invalid, got %s", data.Value, orcid)
+		}
+	}
+}
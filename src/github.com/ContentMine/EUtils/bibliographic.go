@@ -0,0 +1,125 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"strings"
+)
+
+// PubMed end every title with a full stop, and put titles that have been translated into English
+// in square brackets. This returns the title without either.
+func (article PubmedArticle) GetTitle() string {
+
+	if len(article.MedlineCitation.Article) == 0 {
+		return ""
+	}
+
+	title := strings.TrimSpace(article.MedlineCitation.Article[0].ArticleTitle)
+	title = strings.TrimSuffix(title, ".")
+	if strings.HasPrefix(title, "[") && strings.HasSuffix(title, "]") {
+		title = title[1 : len(title)-1]
+	}
+	return strings.TrimSuffix(strings.TrimSpace(title), ".")
+}
+
+// Returns true if the article was published in another language and the title we have is an
+// English translation of the original.
+func (article PubmedArticle) IsTitleTranslated() bool {
+
+	if len(article.MedlineCitation.Article) == 0 {
+		return false
+	}
+	title := strings.TrimSpace(article.MedlineCitation.Article[0].ArticleTitle)
+	return strings.HasPrefix(title, "[")
+}
+
+func (article PubmedArticle) GetVolume() string {
+
+	if len(article.MedlineCitation.Article) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(article.MedlineCitation.Article[0].Journal.JournalIssue.Volume)
+}
+
+func (article PubmedArticle) GetIssue() string {
+
+	if len(article.MedlineCitation.Article) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(article.MedlineCitation.Article[0].Journal.JournalIssue.Issue)
+}
+
+// Returns the pages the article is on, with any abbreviated ranges written out in full, e.g.,
+// "123-9" becomes "123-129".
+func (article PubmedArticle) GetPages() string {
+
+	if len(article.MedlineCitation.Article) == 0 {
+		return ""
+	}
+
+	pagination := article.MedlineCitation.Article[0].Pagination
+	if pagination.MedlinePgn != "" {
+		return ExpandPageRange(pagination.MedlinePgn)
+	}
+	start := strings.TrimSpace(pagination.StartPage)
+	end := strings.TrimSpace(pagination.EndPage)
+	if start != "" && end != "" && start != end {
+		return start + "-" + end
+	}
+	return start
+}
+
+// MEDLINE abbreviate page ranges by dropping the leading digits of the last page that are the same
+// as the first page, so "123-9" is pages 123 to 129. A page can have a prefix, such as "S12-8" for
+// supplement pages S12 to S18, and there can be several ranges separated by commas.
+func ExpandPageRange(pages string) string {
+
+	parts := strings.Split(pages, ",")
+	for idx, part := range parts {
+		parts[idx] = expandPageRangePart(strings.TrimSpace(part))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func expandPageRangePart(part string) string {
+
+	bounds := strings.Split(part, "-")
+	if len(bounds) != 2 {
+		return part
+	}
+	start := strings.TrimSpace(bounds[0])
+	end := strings.TrimSpace(bounds[1])
+
+	idx := len(start)
+	for idx > 0 && isDigit(start[idx-1]) {
+		idx -= 1
+	}
+	prefix, start_digits := start[:idx], start[idx:]
+
+	if start_digits == "" || end == "" || len(end) >= len(start_digits) {
+		return start + "-" + end
+	}
+	for i := 0; i < len(end); i++ {
+		if !isDigit(end[i]) {
+			return start + "-" + end
+		}
+	}
+
+	return start + "-" + prefix + start_digits[:len(start_digits)-len(end)] + end
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
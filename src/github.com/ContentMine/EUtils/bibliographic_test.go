@@ -0,0 +1,103 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"testing"
+)
+
+func TestBibliographicDetails(t *testing.T) {
+
+	testdata := []struct {
+		filename string
+		title    string
+		volume   string
+		issue    string
+		pages    string
+	}{
+		{filename: "testdata/example1.xml", title: "Pathology and pathogenesis of human leptospirosis: a commented review", volume: "60", issue: "", pages: "e23"},
+		{filename: "testdata/retracted.xml", volume: "7", issue: "9", pages: "e2388"},
+		{filename: "testdata/retraction.xml", volume: "10", issue: "2", pages: "60"},
+		{filename: "testdata/topics.xml", volume: "14", issue: "2", pages: "389-398"},
+	}
+
+	for _, data := range testdata {
+		article_set, err := loadXML(data.filename)
+		if err != nil {
+			t.Errorf("Failed to load test data: %v", err)
+			continue
+		}
+		article := article_set.Articles[0]
+
+		if data.title != "" && article.GetTitle() != data.title {
+			t.Errorf("%s: unexpected title %s", data.filename, article.GetTitle())
+		}
+		if article.IsTitleTranslated() {
+			t.Errorf("%s: expected title to not be translated", data.filename)
+		}
+		if article.GetVolume() != data.volume {
+			t.Errorf("%s: unexpected volume %s", data.filename, article.GetVolume())
+		}
+		if article.GetIssue() != data.issue {
+			t.Errorf("%s: unexpected issue %s", data.filename, article.GetIssue())
+		}
+		if article.GetPages() != data.pages {
+			t.Errorf("%s: unexpected pages %s", data.filename, article.GetPages())
+		}
+	}
+}
+
+func TestTranslatedTitle(t *testing.T) {
+
+	article := PubmedArticle{
+		MedlineCitation: MedlineCitation{
+			Article: []Article{{ArticleTitle: "[Leptospirosis in dogs in Brazil]."}},
+		},
+	}
+
+	if !article.IsTitleTranslated() {
+		t.Errorf("Expected title to be translated")
+	}
+	if article.GetTitle() != "Leptospirosis in dogs in Brazil" {
+		t.Errorf("Unexpected title: %s", article.GetTitle())
+	}
+}
+
+func TestExpandPageRange(t *testing.T) {
+
+	testdata := []struct {
+		pages    string
+		expected string
+	}{
+		{pages: "123-9", expected: "123-129"},
+		{pages: "123-29", expected: "123-129"},
+		{pages: "1123-45", expected: "1123-1145"},
+		{pages: "98-102", expected: "98-102"},
+		{pages: "389-398", expected: "389-398"},
+		{pages: "S12-8", expected: "S12-S18"},
+		{pages: "e23", expected: "e23"},
+		{pages: "60", expected: "60"},
+		{pages: "123-9, 145-7", expected: "123-129, 145-147"},
+		{pages: "iii-iv", expected: "iii-iv"},
+		{pages: "", expected: ""},
+	}
+
+	for _, data := range testdata {
+		expanded := ExpandPageRange(data.pages)
+		if expanded != data.expected {
+			t.Errorf("Expected %q to expand to %q, got %q", data.pages, data.expected, expanded)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DEFAULT_BASE_URL string = "https://eutils.ncbi.nlm.nih.gov/entrez/eutils/"
+
+const ESEARCH_ENDPOINT = "esearch.fcgi"
+const EFETCH_ENDPOINT = "efetch.fcgi"
+const ESUMMARY_ENDPOINT = "esummary.fcgi"
+const ELINK_ENDPOINT = "elink.fcgi"
+const EPOST_ENDPOINT = "epost.fcgi"
+const EINFO_ENDPOINT = "einfo.fcgi"
+
+// A Client holds the settings shared by all requests made to the E-utilities. NCBI ask that
+// tools identify themselves with the tool and email parameters, so you can register these
+// with them and get contacted if there's a problem rather than just being blocked.
+//
+// BaseURL can be pointed at a mirror, proxy, or test server. If HTTPClient is nil then
+// http.DefaultClient is used.
+//
+// Requests that fail for transient reasons are retried up to MaxRetries times, with an exponential
+// backoff starting at RetryBaseDelay (or RETRY_BASE_DELAY if that is zero). If Timeout is set then
+// each attempt is abandoned if it takes longer than that, and counts as a transient failure.
+//
+// All requests made through a client are rate limited. NCBI apply their limits per API key, so
+// the client keeps a limiter for each key it sees. If RequestsPerSecond is zero then NCBI's default
+// rates are used. A client is safe to share between goroutines, but the settings should not
+// be changed once it is in use.
+type Client struct {
+	BaseURL           string
+	HTTPClient        *http.Client
+	APIKey            string
+	Tool              string
+	Email             string
+	RequestsPerSecond float64
+	MaxRetries        int
+	RetryBaseDelay    time.Duration
+	Timeout           time.Duration
+
+	limitersLock sync.Mutex
+	limiters     map[string]*RateLimiter
+}
+
+// The client used by the Do() method on each request type
+var DefaultClient = NewClient("")
+
+func NewClient(api_key string) *Client {
+	return &Client{
+		BaseURL:    DEFAULT_BASE_URL,
+		HTTPClient: &http.Client{},
+		APIKey:     api_key,
+		MaxRetries: DEFAULT_MAX_RETRIES,
+	}
+}
+
+func (c *Client) endpointURL(endpoint string) string {
+	base := c.BaseURL
+	if base == "" {
+		base = DEFAULT_BASE_URL
+	}
+	return strings.TrimSuffix(base, "/") + "/" + endpoint
+}
+
+// An API key set on an individual request takes precedence over the one on the client
+func (c *Client) apiKey(request_api_key string) string {
+	if request_api_key != "" {
+		return request_api_key
+	}
+	return c.APIKey
+}
+
+func (c *Client) limiter(api_key string) *RateLimiter {
+
+	c.limitersLock.Lock()
+	defer c.limitersLock.Unlock()
+
+	if c.limiters == nil {
+		c.limiters = make(map[string]*RateLimiter)
+	}
+
+	limiter, ok := c.limiters[api_key]
+	if !ok {
+		rate := c.RequestsPerSecond
+		if rate <= 0 {
+			if api_key != "" {
+				rate = NCBI_REQUESTS_PER_SECOND_WITH_KEY
+			} else {
+				rate = NCBI_REQUESTS_PER_SECOND_WITHOUT_KEY
+			}
+		}
+		limiter = NewRateLimiter(rate)
+		c.limiters[api_key] = limiter
+	}
+	return limiter
+}
+
+// Closing the body of a reply also releases the timeout for the request
+type timeoutBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b timeoutBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// Makes a call to the given E-utility endpoint, adding in the client wide parameters. GET
+// requests put the parameters in the URL, POST requests send them as a form, which NCBI recommend
+// for long lists of IDs. On success the caller must close the response body. Any failure is returned
+// as an *Error so the caller can tell if it is worth retrying.
+func (c *Client) call(ctx context.Context, method string, endpoint string, request_api_key string, params url.Values) (*http.Response, error) {
+
+	api_key := c.apiKey(request_api_key)
+
+	q := url.Values{}
+	for k, v := range params {
+		q[k] = v
+	}
+	if api_key != "" {
+		q.Set("api_key", api_key)
+	}
+	if c.Tool != "" {
+		q.Set("tool", c.Tool)
+	}
+	if c.Email != "" {
+		q.Set("email", c.Email)
+	}
+
+	var body io.Reader
+	target := c.endpointURL(endpoint)
+	if method == "POST" {
+		body = strings.NewReader(q.Encode())
+	} else {
+		target = target + "?" + q.Encode()
+	}
+
+	err := c.limiter(api_key).WaitContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request_ctx := ctx
+	cancel := func() {}
+	if c.Timeout > 0 {
+		request_ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+	}
+
+	req, err := http.NewRequestWithContext(request_ctx, method, target, body)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if method == "POST" {
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	http_client := c.HTTPClient
+	if http_client == nil {
+		http_client = http.DefaultClient
+	}
+	resp, err := http_client.Do(req)
+	if err != nil {
+		cancel()
+		// Failing to get a reply at all is usually a network issue, or our own timeout, so worth
+		// another go, unless the caller has given up on us
+		return nil, &Error{Message: err.Error(), Retryable: ctx.Err() == nil, Err: err}
+	}
+	resp.Body = timeoutBody{ReadCloser: resp.Body, cancel: cancel}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, &Error{
+				StatusCode: resp.StatusCode,
+				Message:    err.Error(),
+				Retryable:  isRetryableStatus(resp.StatusCode),
+				Err:        err,
+			}
+		}
+		return nil, newStatusError(resp, body)
+	}
+
+	return resp, nil
+}
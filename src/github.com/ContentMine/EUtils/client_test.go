@@ -0,0 +1,153 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Serves the given test data file for any request to the endpoint, and records the parameters of
+// each request made
+func newTestServer(t *testing.T, endpoint string, filename string, requests *[]*http.Request) *httptest.Server {
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := r.ParseForm()
+		if err != nil {
+			t.Errorf("Failed to parse request: %v", err)
+		}
+		*requests = append(*requests, r)
+
+		if !strings.HasSuffix(r.URL.Path, "/"+endpoint) {
+			http.NotFound(w, r)
+			return
+		}
+
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Errorf("Failed to load test data: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(data)
+	}))
+}
+
+func TestClientParameters(t *testing.T) {
+
+	requests := make([]*http.Request, 0)
+	server := newTestServer(t, ESEARCH_ENDPOINT, "testdata/esearch.json", &requests)
+	defer server.Close()
+
+	client := NewClient("client-key")
+	client.BaseURL = server.URL + "/eutils/"
+	client.Tool = "testtool"
+	client.Email = "test@example.com"
+
+	search_request := ESearchRequest{
+		DB:   "pubmed",
+		Term: "Leptospirosis[Mesh Major Topic]",
+	}
+	result, err := search_request.DoWithClient(client)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if result.Count != "219" {
+		t.Errorf("Got unexpected count: %s", result.Count)
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("Unexpected number of requests: %d", len(requests))
+	}
+	r := requests[0]
+	if r.URL.Path != "/eutils/esearch.fcgi" {
+		t.Errorf("Request made to unexpected path: %s", r.URL.Path)
+	}
+	expected := map[string]string{
+		"api_key": "client-key",
+		"tool":    "testtool",
+		"email":   "test@example.com",
+		"db":      "pubmed",
+		"term":    "Leptospirosis[Mesh Major Topic]",
+	}
+	for k, v := range expected {
+		if r.Form.Get(k) != v {
+			t.Errorf("Expected %s to be %s, got %s", k, v, r.Form.Get(k))
+		}
+	}
+
+	// A key on the request should override the one on the client
+	search_request.APIKey = "request-key"
+	_, err = search_request.DoWithClient(client)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if requests[1].Form.Get("api_key") != "request-key" {
+		t.Errorf("Expected request API key to be used, got %s", requests[1].Form.Get("api_key"))
+	}
+}
+
+func TestClientStatusError(t *testing.T) {
+
+	requests := make([]*http.Request, 0)
+	server := newTestServer(t, ESEARCH_ENDPOINT, "testdata/esearch.json", &requests)
+	defer server.Close()
+
+	client := NewClient("client-key")
+	client.BaseURL = server.URL
+
+	fetch_request := EFetchRequest{
+		DB:  "pubmed",
+		IDs: []string{"29846473"},
+	}
+	_, err := fetch_request.DoWithClient(client)
+	if err == nil {
+		t.Errorf("Expected error fetching from missing endpoint")
+	}
+}
+
+func TestClientEFetchChunks(t *testing.T) {
+
+	requests := make([]*http.Request, 0)
+	server := newTestServer(t, EFETCH_ENDPOINT, "testdata/example1.xml", &requests)
+	defer server.Close()
+
+	client := NewClient("client-key")
+	client.BaseURL = server.URL
+
+	fetch_request := EFetchRequest{
+		DB:        "pubmed",
+		IDs:       []string{"1", "2", "3", "4", "5"},
+		ChunkSize: 2,
+	}
+	result, err := fetch_request.DoWithClient(client)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	if len(requests) != 3 {
+		t.Errorf("Unexpected number of requests: %d", len(requests))
+	} else if requests[2].Method != "POST" || requests[2].PostForm.Get("id") != "5" {
+		t.Errorf("Unexpected final request: %s %v", requests[2].Method, requests[2].PostForm)
+	}
+
+	// Our server returns one article per request
+	if len(result.Articles) != 3 {
+		t.Errorf("Unexpected number of articles: %d", len(result.Articles))
+	}
+}
@@ -0,0 +1,97 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+// The ways NLM link one publication to another in CommentsCorrections. Most come in pairs, e.g.,
+// an article has RetractionIn pointing at its retraction notice, and the notice has RetractionOf
+// pointing back at the article.
+const REF_TYPE_ASSOCIATED_DATASET = "AssociatedDataset"
+const REF_TYPE_ASSOCIATED_PUBLICATION = "AssociatedPublication"
+const REF_TYPE_CITES = "Cites"
+const REF_TYPE_COMMENT_IN = "CommentIn"
+const REF_TYPE_COMMENT_ON = "CommentOn"
+const REF_TYPE_CORRECTED_AND_REPUBLISHED_IN = "CorrectedandRepublishedIn"
+const REF_TYPE_CORRECTED_AND_REPUBLISHED_FROM = "CorrectedandRepublishedFrom"
+const REF_TYPE_ERRATUM_IN = "ErratumIn"
+const REF_TYPE_ERRATUM_FOR = "ErratumFor"
+const REF_TYPE_EXPRESSION_OF_CONCERN_IN = "ExpressionOfConcernIn"
+const REF_TYPE_EXPRESSION_OF_CONCERN_FOR = "ExpressionOfConcernFor"
+const REF_TYPE_ORIGINAL_REPORT_IN = "OriginalReportIn"
+const REF_TYPE_REPRINT_IN = "ReprintIn"
+const REF_TYPE_REPRINT_OF = "ReprintOf"
+const REF_TYPE_REPUBLISHED_IN = "RepublishedIn"
+const REF_TYPE_REPUBLISHED_FROM = "RepublishedFrom"
+const REF_TYPE_RETRACTED_AND_REPUBLISHED_IN = "RetractedandRepublishedIn"
+const REF_TYPE_RETRACTED_AND_REPUBLISHED_FROM = "RetractedandRepublishedFrom"
+const REF_TYPE_RETRACTION_IN = "RetractionIn"
+const REF_TYPE_RETRACTION_OF = "RetractionOf"
+const REF_TYPE_SUMMARY_FOR_PATIENTS_IN = "SummaryForPatientsIn"
+const REF_TYPE_UPDATE_IN = "UpdateIn"
+const REF_TYPE_UPDATE_OF = "UpdateOf"
+
+// The relation as seen from the other publication, for those that come in pairs
+var REF_TYPE_INVERSES = map[string]string{
+	REF_TYPE_COMMENT_IN:                     REF_TYPE_COMMENT_ON,
+	REF_TYPE_COMMENT_ON:                     REF_TYPE_COMMENT_IN,
+	REF_TYPE_CORRECTED_AND_REPUBLISHED_IN:   REF_TYPE_CORRECTED_AND_REPUBLISHED_FROM,
+	REF_TYPE_CORRECTED_AND_REPUBLISHED_FROM: REF_TYPE_CORRECTED_AND_REPUBLISHED_IN,
+	REF_TYPE_ERRATUM_IN:                     REF_TYPE_ERRATUM_FOR,
+	REF_TYPE_ERRATUM_FOR:                    REF_TYPE_ERRATUM_IN,
+	REF_TYPE_EXPRESSION_OF_CONCERN_IN:       REF_TYPE_EXPRESSION_OF_CONCERN_FOR,
+	REF_TYPE_EXPRESSION_OF_CONCERN_FOR:      REF_TYPE_EXPRESSION_OF_CONCERN_IN,
+	REF_TYPE_REPRINT_IN:                     REF_TYPE_REPRINT_OF,
+	REF_TYPE_REPRINT_OF:                     REF_TYPE_REPRINT_IN,
+	REF_TYPE_REPUBLISHED_IN:                 REF_TYPE_REPUBLISHED_FROM,
+	REF_TYPE_REPUBLISHED_FROM:               REF_TYPE_REPUBLISHED_IN,
+	REF_TYPE_RETRACTED_AND_REPUBLISHED_IN:   REF_TYPE_RETRACTED_AND_REPUBLISHED_FROM,
+	REF_TYPE_RETRACTED_AND_REPUBLISHED_FROM: REF_TYPE_RETRACTED_AND_REPUBLISHED_IN,
+	REF_TYPE_RETRACTION_IN:                  REF_TYPE_RETRACTION_OF,
+	REF_TYPE_RETRACTION_OF:                  REF_TYPE_RETRACTION_IN,
+	REF_TYPE_UPDATE_IN:                      REF_TYPE_UPDATE_OF,
+	REF_TYPE_UPDATE_OF:                      REF_TYPE_UPDATE_IN,
+}
+
+// Returns all the links from this article to other publications, of whatever type
+func (article PubmedArticle) GetCommentsCorrections() []CommentsCorrections {
+	return article.MedlineCitation.CommentsCorrectionsList.CommentsCorrections
+}
+
+// Returns the links from this article of the given types
+func (article PubmedArticle) GetCommentsCorrectionsOfType(ref_types ...string) []CommentsCorrections {
+
+	links := make([]CommentsCorrections, 0)
+	for _, link := range article.GetCommentsCorrections() {
+		for _, ref_type := range ref_types {
+			if link.RefType == ref_type {
+				links = append(links, link)
+				break
+			}
+		}
+	}
+	return links
+}
+
+// Returns the PMIDs of the publications linked to by the given type of relation, skipping those
+// that aren't in PubMed
+func (article PubmedArticle) GetRelatedPMIDs(ref_type string) []string {
+
+	pmids := make([]string, 0)
+	for _, link := range article.GetCommentsCorrectionsOfType(ref_type) {
+		if link.PMID != "" {
+			pmids = append(pmids, link.PMID)
+		}
+	}
+	return pmids
+}
@@ -0,0 +1,90 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"testing"
+)
+
+func TestCommentsCorrections(t *testing.T) {
+
+	testdata := []struct {
+		filename      string
+		count         int
+		ref_type      string
+		related_pmids []string
+	}{
+		{"testdata/example1.xml", 1, REF_TYPE_ERRATUM_IN, []string{"29972465"}},
+		{"testdata/retracted.xml", 2, REF_TYPE_RETRACTION_IN, []string{"30683838"}},
+		{"testdata/retracted.xml", 2, REF_TYPE_ERRATUM_IN, []string{"30082717"}},
+		{"testdata/retraction.xml", 1, REF_TYPE_RETRACTION_OF, []string{"27685632"}},
+		{"testdata/retraction.xml", 1, REF_TYPE_RETRACTION_IN, []string{}},
+	}
+
+	for _, testitem := range testdata {
+		article_set, err := loadXML(testitem.filename)
+		if err != nil {
+			t.Errorf("Failed to load test data: %v", err)
+			continue
+		}
+		article := article_set.Articles[0]
+
+		links := article.GetCommentsCorrections()
+		if len(links) != testitem.count {
+			t.Errorf("%s: expected %d links, got %d", testitem.filename, testitem.count, len(links))
+		}
+
+		pmids := article.GetRelatedPMIDs(testitem.ref_type)
+		if len(pmids) != len(testitem.related_pmids) {
+			t.Errorf("%s: expected %v for %s, got %v", testitem.filename, testitem.related_pmids, testitem.ref_type, pmids)
+			continue
+		}
+		for idx, pmid := range testitem.related_pmids {
+			if pmids[idx] != pmid {
+				t.Errorf("%s: expected %s for %s, got %s", testitem.filename, pmid, testitem.ref_type, pmids[idx])
+			}
+		}
+	}
+}
+
+func TestCommentsCorrectionsOfType(t *testing.T) {
+
+	article_set, err := loadXML("testdata/retracted.xml")
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+	article := article_set.Articles[0]
+
+	links := article.GetCommentsCorrectionsOfType(REF_TYPE_RETRACTION_IN, REF_TYPE_ERRATUM_IN)
+	if len(links) != 2 {
+		t.Errorf("Expected 2 links, got %d", len(links))
+	}
+	links = article.GetCommentsCorrectionsOfType(REF_TYPE_COMMENT_ON)
+	if len(links) != 0 {
+		t.Errorf("Expected no comment links, got %d", len(links))
+	}
+	if links := article.GetCommentsCorrectionsOfType(REF_TYPE_RETRACTION_IN); links[0].RefSource != "Cell Death Dis. 2019 Jan 25;10(2):60" {
+		t.Errorf("Unexpected ref source: %s", links[0].RefSource)
+	}
+}
+
+func TestRefTypeInverses(t *testing.T) {
+
+	for ref_type, inverse := range REF_TYPE_INVERSES {
+		if REF_TYPE_INVERSES[inverse] != ref_type {
+			t.Errorf("Inverse of %s is %s, but its inverse is %s", ref_type, inverse, REF_TYPE_INVERSES[inverse])
+		}
+	}
+}
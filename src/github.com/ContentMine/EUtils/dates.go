@@ -0,0 +1,247 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// These match the precision values Wikidata uses for time values
+const DATE_PRECISION_YEAR = 9
+const DATE_PRECISION_MONTH = 10
+const DATE_PRECISION_DAY = 11
+
+// A date that is only known to a given precision. Parts finer than the precision are zero.
+type PublicationDate struct {
+	Year      int
+	Month     int
+	Day       int
+	Precision int
+}
+
+func (d PublicationDate) IsZero() bool {
+	return d.Year == 0
+}
+
+// Returns the date in the form Wikidata uses, e.g., "+2018-03-00T00:00:00Z/10" for March 2018, or
+// an empty string for a zero date.
+func (d PublicationDate) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("+%04d-%02d-%02dT00:00:00Z/%d", d.Year, d.Month, d.Day, d.Precision)
+}
+
+// Returns true if d is before other. The dates are only compared as far as the less precise of
+// the two allows, and if they overlap, e.g., 2018 and 2018-05-28, the more precise is taken as the
+// earlier, as it tells us more about when the article came out.
+func (d PublicationDate) Before(other PublicationDate) bool {
+	if d.Year != other.Year {
+		return d.Year < other.Year
+	}
+	if d.Precision >= DATE_PRECISION_MONTH && other.Precision >= DATE_PRECISION_MONTH && d.Month != other.Month {
+		return d.Month < other.Month
+	}
+	if d.Precision >= DATE_PRECISION_DAY && other.Precision >= DATE_PRECISION_DAY && d.Day != other.Day {
+		return d.Day < other.Day
+	}
+	return d.Precision > other.Precision
+}
+
+func newPublicationDate(year int, month int, day int) PublicationDate {
+	if month == 0 {
+		return PublicationDate{Year: year, Precision: DATE_PRECISION_YEAR}
+	}
+	if day == 0 {
+		return PublicationDate{Year: year, Month: month, Precision: DATE_PRECISION_MONTH}
+	}
+	return PublicationDate{Year: year, Month: month, Day: day, Precision: DATE_PRECISION_DAY}
+}
+
+// An article can have two dates: the date of the journal issue it is in (PubDate), and the date
+// it was published electronically (ArticleDate). These often differ, and either can be missing.
+type DateRule string
+
+// Use the journal issue date, falling back to the electronic date if there isn't one
+const DATE_RULE_PUBDATE = DateRule("pubdate")
+
+// Use the electronic date, falling back to the journal issue date if there isn't one
+const DATE_RULE_ARTICLEDATE = DateRule("articledate")
+
+// Use whichever date is earlier, as that is when the article was first available. If the dates
+// overlap, e.g., the issue date is just a year, the more precise one is used.
+const DATE_RULE_EARLIEST = DateRule("earliest")
+
+const DEFAULT_DATE_RULE = DATE_RULE_EARLIEST
+
+var DATE_RULES = []DateRule{DATE_RULE_PUBDATE, DATE_RULE_ARTICLEDATE, DATE_RULE_EARLIEST}
+
+func (rule DateRule) IsValid() bool {
+	for _, r := range DATE_RULES {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// Picks the article's publication date by the given rule. If the rule is empty the default is used.
+// Returns a zero date if the article has no usable date.
+func (article PubmedArticle) GetPublicationDate(rule DateRule) PublicationDate {
+
+	pubdate, _ := article.GetPubDate()
+	articledate, _ := article.GetArticleDate()
+
+	if rule == "" {
+		rule = DEFAULT_DATE_RULE
+	}
+
+	switch rule {
+	case DATE_RULE_ARTICLEDATE:
+		if !articledate.IsZero() {
+			return articledate
+		}
+		return pubdate
+	case DATE_RULE_EARLIEST:
+		if pubdate.IsZero() {
+			return articledate
+		}
+		if !articledate.IsZero() && articledate.Before(pubdate) {
+			return articledate
+		}
+		return pubdate
+	default:
+		if !pubdate.IsZero() {
+			return pubdate
+		}
+		return articledate
+	}
+}
+
+// Returns the date of the journal issue the article is in
+func (article PubmedArticle) GetPubDate() (PublicationDate, error) {
+
+	if len(article.MedlineCitation.Article) == 0 {
+		return PublicationDate{}, fmt.Errorf("Article has no journal issue")
+	}
+	p := article.MedlineCitation.Article[0].Journal.JournalIssue.PubDate
+
+	if p.MedlineDate != "" {
+		return ParseMedlineDate(p.MedlineDate)
+	}
+	if p.Year == 0 {
+		return PublicationDate{}, fmt.Errorf("Publication date has no year")
+	}
+	if p.Season != "" || p.Month == "" {
+		return newPublicationDate(p.Year, 0, 0), nil
+	}
+
+	month, err := monthStringToInt(p.Month)
+	if err != nil {
+		return newPublicationDate(p.Year, 0, 0), nil
+	}
+	return newPublicationDate(p.Year, month, p.Day), nil
+}
+
+// Returns the date the article was published electronically
+func (article PubmedArticle) GetArticleDate() (PublicationDate, error) {
+
+	if len(article.MedlineCitation.Article) == 0 {
+		return PublicationDate{}, fmt.Errorf("Article has no article date")
+	}
+	p := article.MedlineCitation.Article[0].ArticleDate
+
+	if p.Year == 0 {
+		return PublicationDate{}, fmt.Errorf("Article date has no year")
+	}
+	if p.Month < 1 || p.Month > 12 {
+		return newPublicationDate(p.Year, 0, 0), nil
+	}
+	return newPublicationDate(p.Year, p.Month, p.Day), nil
+}
+
+var SEASONS = map[string]bool{
+	"spring": true,
+	"summer": true,
+	"fall":   true,
+	"autumn": true,
+	"winter": true,
+}
+
+// Parses the free text dates NLM use when a date doesn't fit the usual pattern, such as
+// "2018 Mar-Apr", "Winter 2017", "2016 Dec 15-21", or "1998-1999". The result is only as
+// precise as the text allows: a single day gives day precision, a range of days within a month
+// gives month precision, and anything broader within a single year gives year precision. Dates
+// that span more than one year can't be represented, so give an error.
+func ParseMedlineDate(medline_date string) (PublicationDate, error) {
+
+	fields := strings.FieldsFunc(medline_date, func(r rune) bool {
+		return r == ' ' || r == '-' || r == ',' || r == '/'
+	})
+
+	years := make([]int, 0)
+	months := make([]int, 0)
+	days := make([]int, 0)
+	season := false
+
+	for _, field := range fields {
+		lower := strings.ToLower(strings.TrimSuffix(field, "."))
+		if SEASONS[lower] {
+			season = true
+			continue
+		}
+		if month, ok := MONTH_TO_INT[lower]; ok {
+			months = appendUnique(months, month)
+			continue
+		}
+		if len(lower) > 3 {
+			if month, ok := MONTH_TO_INT[lower[:3]]; ok {
+				months = appendUnique(months, month)
+				continue
+			}
+		}
+		number, err := strconv.Atoi(lower)
+		if err != nil {
+			continue
+		}
+		if len(lower) == 4 {
+			years = appendUnique(years, number)
+		} else if number >= 1 && number <= 31 {
+			days = appendUnique(days, number)
+		}
+	}
+
+	if len(years) != 1 {
+		return PublicationDate{}, fmt.Errorf("Failed to find a single year in %s", medline_date)
+	}
+	if season || len(months) != 1 {
+		return newPublicationDate(years[0], 0, 0), nil
+	}
+	if len(days) != 1 {
+		return newPublicationDate(years[0], months[0], 0), nil
+	}
+	return newPublicationDate(years[0], months[0], days[0]), nil
+}
+
+func appendUnique(list []int, value int) []int {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
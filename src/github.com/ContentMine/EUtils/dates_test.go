@@ -0,0 +1,112 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"testing"
+)
+
+func TestParseMedlineDate(t *testing.T) {
+
+	testdata := []struct {
+		text     string
+		expected string
+	}{
+		{"2018 Mar-Apr", "+2018-00-00T00:00:00Z/9"},
+		{"Winter 2017", "+2017-00-00T00:00:00Z/9"},
+		{"2017 Winter", "+2017-00-00T00:00:00Z/9"},
+		{"2016 Dec 15-21", "+2016-12-00T00:00:00Z/10"},
+		{"2016 Dec 15", "+2016-12-15T00:00:00Z/11"},
+		{"2015 June", "+2015-06-00T00:00:00Z/10"},
+		{"2015", "+2015-00-00T00:00:00Z/9"},
+		{"2014 Jan 15-Feb 1", "+2014-00-00T00:00:00Z/9"},
+		{"1998 Dec-1999 Jan", ""},
+		{"1998-1999", ""},
+		{"Spring", ""},
+	}
+
+	for _, testitem := range testdata {
+		date, err := ParseMedlineDate(testitem.text)
+		if testitem.expected == "" {
+			if err == nil {
+				t.Errorf("Expected error parsing %s, got %v", testitem.text, date)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Failed to parse %s: %v", testitem.text, err)
+			continue
+		}
+		if date.String() != testitem.expected {
+			t.Errorf("Parsed %s as %s, expected %s", testitem.text, date.String(), testitem.expected)
+		}
+	}
+}
+
+func TestPublicationDateRules(t *testing.T) {
+
+	testdata := []struct {
+		filename    string
+		pubdate     string
+		articledate string
+		earliest    string
+	}{
+		{"testdata/example1.xml", "+2018-00-00T00:00:00Z/9", "+2018-05-28T00:00:00Z/11", "+2018-05-28T00:00:00Z/11"},
+		{"testdata/topics.xml", "+2017-06-00T00:00:00Z/10", "+2017-04-12T00:00:00Z/11", "+2017-04-12T00:00:00Z/11"},
+		{"testdata/retraction.xml", "+2019-01-25T00:00:00Z/11", "+2019-01-25T00:00:00Z/11", "+2019-01-25T00:00:00Z/11"},
+	}
+
+	for _, testitem := range testdata {
+		article_set, err := loadXML(testitem.filename)
+		if err != nil {
+			t.Errorf("Failed to load test data: %v", err)
+			continue
+		}
+		article := article_set.Articles[0]
+
+		if d := article.GetPublicationDate(DATE_RULE_PUBDATE).String(); d != testitem.pubdate {
+			t.Errorf("%s: pubdate rule gave %s, expected %s", testitem.filename, d, testitem.pubdate)
+		}
+		if d := article.GetPublicationDate(DATE_RULE_ARTICLEDATE).String(); d != testitem.articledate {
+			t.Errorf("%s: articledate rule gave %s, expected %s", testitem.filename, d, testitem.articledate)
+		}
+		if d := article.GetPublicationDate(DATE_RULE_EARLIEST).String(); d != testitem.earliest {
+			t.Errorf("%s: earliest rule gave %s, expected %s", testitem.filename, d, testitem.earliest)
+		}
+		if d := article.GetPublicationDateString(); d != testitem.earliest {
+			t.Errorf("%s: default rule gave %s, expected %s", testitem.filename, d, testitem.earliest)
+		}
+	}
+}
+
+func TestPublicationDateFallback(t *testing.T) {
+
+	article := PubmedArticle{}
+	article.MedlineCitation.Article = make([]Article, 1)
+	article.MedlineCitation.Article[0].Journal.JournalIssue.PubDate.MedlineDate = "2018 Mar-Apr"
+
+	for _, rule := range DATE_RULES {
+		d := article.GetPublicationDate(rule).String()
+		if d != "+2018-00-00T00:00:00Z/9" {
+			t.Errorf("Rule %s gave %s when only the MedlineDate was present", rule, d)
+		}
+	}
+
+	empty := PubmedArticle{}
+	empty.MedlineCitation.Article = make([]Article, 1)
+	if d := empty.GetPublicationDateString(); d != "" {
+		t.Errorf("Expected no date for an article without dates, got %s", d)
+	}
+}
@@ -15,16 +15,15 @@
 package EUtils
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
-	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 )
 
-const EFETCH_URL string = "https://eutils.ncbi.nlm.nih.gov/entrez/eutils/efetch.fcgi"
-
 type PubmedArticleSet struct {
 	XMLName  xml.Name        `xml:"PubmedArticleSet"`
 	Articles []PubmedArticle `xml:"PubmedArticle"`
@@ -42,6 +41,8 @@ type MedlineCitation struct {
 	Owner                   string                  `xml:"Owner,attr"`
 	PMID                    string                  `xml:"PMID"`
 	Article                 []Article               `xml:"Article"`
+	ChemicalList            ChemicalList            `xml:"ChemicalList"`
+	SupplMeshList           SupplMeshList           `xml:"SupplMeshList"`
 	MeshHeadingList         MeshHeadingList         `xml:"MeshHeadingList"`
 	CommentsCorrectionsList CommentsCorrectionsList `xml:"CommentsCorrectionsList"`
 }
@@ -51,16 +52,39 @@ type Article struct {
 	PubModel            string              `xml:"PubModel,attr"`
 	ArticleTitle        string              `xml:"ArticleTitle"`
 	Journal             Journal             `xml:"Journal"`
+	Pagination          Pagination          `xml:"Pagination"`
+	Abstract            Abstract            `xml:"Abstract"`
+	AuthorList          AuthorList          `xml:"AuthorList"`
+	GrantList           GrantList           `xml:"GrantList"`
+	ELocationIDs        []ELocationID       `xml:"ELocationID"`
 	Language            string              `xml:"Language"`
 	PublicationTypeList PublicationTypeList `xml:"PublicationTypeList"`
 	ArticleDate         ArticleDate         `xml:"ArticleDate"`
 }
 
+// MedlinePgn is the form normally given, with ranges abbreviated, e.g., "123-9"
+type Pagination struct {
+	XMLName    xml.Name `xml:"Pagination"`
+	StartPage  string   `xml:"StartPage"`
+	EndPage    string   `xml:"EndPage"`
+	MedlinePgn string   `xml:"MedlinePgn"`
+}
+
+// An electronic location for the article, such as a DOI or publisher item identifier (pii)
+type ELocationID struct {
+	XMLName xml.Name `xml:"ELocationID"`
+	EIdType string   `xml:"EIdType,attr"`
+	ValidYN string   `xml:"ValidYN,attr"`
+	Value   string   `xml:",chardata"`
+}
+
+// The date the article was published electronically
 type ArticleDate struct {
-	XMLName xml.Name `xml:"ArticleDate"`
-	Year    int      `xml:"Year"`
-	Month   int      `xml:"Month"`
-	Day     int      `xml:"Day"`
+	XMLName  xml.Name `xml:"ArticleDate"`
+	DateType string   `xml:"DateType,attr"`
+	Year     int      `xml:"Year"`
+	Month    int      `xml:"Month"`
+	Day      int      `xml:"Day"`
 }
 
 type PublicationTypeList struct {
@@ -90,11 +114,15 @@ type JournalIssue struct {
 	PubDate    PubDate  `xml:"PubDate"`
 }
 
+// The date of the journal issue the article is in. Dates that don't fit the Year/Month/Day
+// pattern, such as "2018 Mar-Apr", are given as free text in MedlineDate instead.
 type PubDate struct {
-	XMLName xml.Name `xml:"PubDate"`
-	Year    int      `xml:"Year"`
-	Month   string   `xml:"Month"`
-	Day     int      `xml:"Day"`
+	XMLName     xml.Name `xml:"PubDate"`
+	Year        int      `xml:"Year"`
+	Month       string   `xml:"Month"`
+	Day         int      `xml:"Day"`
+	Season      string   `xml:"Season"`
+	MedlineDate string   `xml:"MedlineDate"`
 }
 
 type MeshHeadingList struct {
@@ -127,17 +155,22 @@ type CommentsCorrectionsList struct {
 	CommentsCorrections []CommentsCorrections `xml:"CommentsCorrections"`
 }
 
+// A link to another publication, such as a retraction notice or erratum. RefType says how the two
+// are related, see the REF_TYPE_ constants. The related publication may not be in PubMed, in
+// which case only RefSource, a citation for it, is given.
 type CommentsCorrections struct {
 	XMLName   xml.Name `xml:"CommentsCorrections"`
 	RefType   string   `xml:"RefType,attr"`
 	RefSource string   `xml:"RefSource"`
 	PMID      string   `xml:"PMID"`
+	Note      string   `xml:"Note"`
 }
 
 type PubMedData struct {
-	XMLName           xml.Name      `xml:"PubmedData"`
-	ArticleIDList     ArticleIdList `xml:"ArticleIdList"`
-	PublicationStatus string        `xml:"PublicationStatus"`
+	XMLName           xml.Name        `xml:"PubmedData"`
+	ArticleIDList     ArticleIdList   `xml:"ArticleIdList"`
+	PublicationStatus string          `xml:"PublicationStatus"`
+	ReferenceLists    []ReferenceList `xml:"ReferenceList"`
 }
 
 type ArticleIdList struct {
@@ -160,19 +193,35 @@ type EFetchHistoryRequest struct {
 	RetStart int
 }
 
+// Used if no chunk size is specified on an EFetchRequest. NCBI recommend not fetching more than
+// a few hundred records at a time.
+const EFETCH_DEFAULT_CHUNK_SIZE = 200
+
+// Fetches a list of specific IDs, rather than results from the history server. Large lists are split
+// into chunks of ChunkSize IDs, and the results merged into a single article set.
+type EFetchRequest struct {
+	DB        string
+	APIKey    string
+	IDs       []string
+	ChunkSize int
+}
+
 func (e *EFetchHistoryRequest) Do() (PubmedArticleSet, error) {
+	return e.DoWithClient(DefaultClient)
+}
 
-	if e.APIKey == "" {
-		return PubmedArticleSet{}, fmt.Errorf("No API Key provided.")
-	}
+func (e *EFetchHistoryRequest) DoWithClient(c *Client) (PubmedArticleSet, error) {
+	return e.DoWithContext(context.Background(), c)
+}
 
-	req, err := http.NewRequest("GET", EFETCH_URL, nil)
-	if err != nil {
-		return PubmedArticleSet{}, err
-	}
+// Like DoWithClient, but the request is abandoned if the context is cancelled
+func (e *EFetchHistoryRequest) DoWithContext(ctx context.Context, c *Client) (PubmedArticleSet, error) {
+	return doEFetch(ctx, c, "GET", e.APIKey, e.params())
+}
 
-	q := req.URL.Query()
-	q.Add("api_key", e.APIKey)
+func (e *EFetchHistoryRequest) params() url.Values {
+
+	q := url.Values{}
 	q.Add("db", e.DB)
 	q.Add("WebEnv", e.WebEnv)
 	q.Add("query_key", e.QueryKey)
@@ -183,26 +232,74 @@ func (e *EFetchHistoryRequest) Do() (PubmedArticleSet, error) {
 	if e.RetStart > 0 {
 		q.Add("retstart", fmt.Sprintf("%d", e.RetStart))
 	}
-	req.URL.RawQuery = q.Encode()
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return PubmedArticleSet{}, err
+	return q
+}
+
+func (e *EFetchRequest) Do() (PubmedArticleSet, error) {
+	return e.DoWithClient(DefaultClient)
+}
+
+func (e *EFetchRequest) DoWithClient(c *Client) (PubmedArticleSet, error) {
+	return e.DoWithContext(context.Background(), c)
+}
+
+// Like DoWithClient, but the request is abandoned if the context is cancelled
+func (e *EFetchRequest) DoWithContext(ctx context.Context, c *Client) (PubmedArticleSet, error) {
+
+	chunk_size := e.ChunkSize
+	if chunk_size <= 0 {
+		chunk_size = EFETCH_DEFAULT_CHUNK_SIZE
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, err := ioutil.ReadAll(resp.Body)
+	result := PubmedArticleSet{Articles: make([]PubmedArticle, 0, len(e.IDs))}
+
+	for _, chunk := range splitIDs(e.IDs, chunk_size) {
+
+		// We use POST here as NCBI recommend it for long lists of IDs
+		q := url.Values{}
+		q.Add("db", e.DB)
+		q.Add("id", strings.Join(chunk, ","))
+		q.Add("retmode", "xml")
+
+		efetch_resp, err := doEFetch(ctx, c, "POST", e.APIKey, q)
 		if err != nil {
-			return PubmedArticleSet{}, fmt.Errorf("Status code %d", resp.StatusCode)
-		} else {
-			return PubmedArticleSet{}, fmt.Errorf("Status code %d: %s", resp.StatusCode, body)
+			return PubmedArticleSet{}, err
 		}
+		result.Articles = append(result.Articles, efetch_resp.Articles...)
 	}
 
-	efetch_resp := PubmedArticleSet{}
-	err = xml.NewDecoder(resp.Body).Decode(&efetch_resp)
+	return result, nil
+}
+
+func doEFetch(ctx context.Context, c *Client, method string, api_key string, params url.Values) (PubmedArticleSet, error) {
+
+	var efetch_resp PubmedArticleSet
+	err := c.retry(ctx, func() error {
+		resp, err := c.call(ctx, method, EFETCH_ENDPOINT, api_key, params)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return &Error{Message: err.Error(), Retryable: true, Err: err}
+		}
+
+		efetch_resp = PubmedArticleSet{}
+		err = xml.Unmarshal(body, &efetch_resp)
+		if err != nil {
+			// NCBI report some failures, such as an expired WebEnv, in an error document
+			// rather than the article set we asked for
+			message := parseErrorBody(body)
+			if message != "" {
+				return newAPIError(message)
+			}
+			return err
+		}
+		return nil
+	})
 	if err != nil {
 		return PubmedArticleSet{}, err
 	}
@@ -210,20 +307,111 @@ func (e *EFetchHistoryRequest) Do() (PubmedArticleSet, error) {
 	return efetch_resp, nil
 }
 
+// Splits a list of IDs into chunks of at most size IDs each
+func splitIDs(ids []string, size int) [][]string {
+
+	chunks := make([][]string, 0, (len(ids)+size-1)/size)
+	for i := 0; i < len(ids); i += size {
+		j := i + size
+		if len(ids) < j {
+			j = len(ids)
+		}
+		chunks = append(chunks, ids[i:j])
+	}
+	return chunks
+}
+
 func (article PubmedArticle) GetPMID() string {
 	return article.MedlineCitation.PMID
 }
 
+// Returns the ID of the given type (e.g., "pubmed", "pmc", "doi", "pii", "mid") from the article's
+// ID list, or an empty string if it doesn't have one.
+func (article PubmedArticle) GetArticleID(id_type string) string {
+	return article.PubMedData.ArticleIDList.GetID(id_type)
+}
+
+func (list ArticleIdList) GetID(id_type string) string {
+
+	for _, articleID := range list.ArticleIDs {
+		if articleID.IDType == id_type {
+			return strings.TrimSpace(articleID.ID)
+		}
+	}
+	return ""
+}
+
 func (article PubmedArticle) GetPMCID() string {
+	return strings.TrimPrefix(article.GetArticleID("pmc"), "PMC")
+}
 
-	for _, articleID := range article.PubMedData.ArticleIDList.ArticleIDs {
-		if articleID.IDType == "pmc" {
-			return strings.TrimPrefix(articleID.ID, "PMC")
+// Returns the article's DOI in the upper case form Wikidata uses, taken from the ID list or failing
+// that from the article's electronic locations. Returns an empty string if there is no DOI.
+func (article PubmedArticle) GetDOI() string {
+
+	doi := NormaliseDOI(article.GetArticleID("doi"))
+	if doi != "" {
+		return doi
+	}
+
+	for _, a := range article.MedlineCitation.Article {
+		for _, location := range a.ELocationIDs {
+			if location.EIdType == "doi" && location.ValidYN != "N" {
+				doi = NormaliseDOI(location.Value)
+				if doi != "" {
+					return doi
+				}
+			}
 		}
 	}
 	return ""
 }
 
+var DOI_PREFIXES = []string{
+	"https://doi.org/",
+	"http://doi.org/",
+	"https://dx.doi.org/",
+	"http://dx.doi.org/",
+	"doi.org/",
+	"doi:",
+}
+
+// DOIs are case insensitive, and Wikidata store them upper case. Any URL or "doi:" prefix is
+// removed. Returns an empty string if what's left doesn't look like a DOI.
+func NormaliseDOI(value string) string {
+
+	doi := strings.TrimSpace(value)
+	for _, prefix := range DOI_PREFIXES {
+		if len(doi) >= len(prefix) && strings.EqualFold(doi[:len(prefix)], prefix) {
+			doi = strings.TrimSpace(doi[len(prefix):])
+			break
+		}
+	}
+
+	if !strings.HasPrefix(doi, "10.") || !strings.Contains(doi, "/") {
+		return ""
+	}
+	return strings.ToUpper(doi)
+}
+
+// Returns the MeSH descriptors that are neither major topics themselves nor have a qualifier that
+// is a major topic
+func (article PubmedArticle) GetMinorTopics() []MeshDescriptorName {
+
+	subjects := make([]MeshDescriptorName, 0)
+	for _, mesh := range article.MedlineCitation.MeshHeadingList.MeshHeadings {
+		major := mesh.DescriptorName.MajorTopicYN == "Y"
+		for _, qual := range mesh.QualifierNames {
+			major = major || qual.MajorTopicYN == "Y"
+		}
+		if !major {
+			subjects = append(subjects, mesh.DescriptorName)
+		}
+	}
+
+	return subjects
+}
+
 func (article PubmedArticle) GetMajorTopics() []MeshDescriptorName {
 
 	subjects := make([]MeshDescriptorName, 0)
@@ -272,68 +460,57 @@ func monthStringToInt(m string) (int, error) {
 	return 0, fmt.Errorf("Failed to translate month %s to number", m)
 }
 
+// Returns the publication date in Wikidata's format, picked using the default rule. Returns an
+// empty string if the article has no usable date.
 func (article PubmedArticle) GetPublicationDateString() string {
+	return article.GetPublicationDate(DEFAULT_DATE_RULE).String()
+}
 
-	pubdate := ""
-	p := article.MedlineCitation.Article[0].Journal.JournalIssue.PubDate
-	if p.Month != "" && p.Year != 0 {
-		pmonth, err := monthStringToInt(p.Month)
-		if err == nil && p.Year != 0 {
-			if p.Day == 0 {
-				p.Day = 1
-			}
-			pubdate = fmt.Sprintf("+%04d-%02d-%02dT00:00:00Z/11", p.Year, pmonth, p.Day)
-		}
-	}
-	if pubdate == "" {
-		p := article.MedlineCitation.Article[0].ArticleDate
-		if p.Month != 0 && p.Year != 0 {
-			if p.Day == 0 {
-				p.Day = 1
-			}
-			pubdate = fmt.Sprintf("+%04d-%02d-%02dT00:00:00Z/11", p.Year, p.Month, p.Day)
-		}
-	}
+// NLM's unique IDs for the publication types we check for directly. These are stable, whereas the
+// names are occasionally revised.
+const PUBLICATION_TYPE_REVIEW = "D016454"
+const PUBLICATION_TYPE_SYSTEMATIC_REVIEW = "D000078182"
+const PUBLICATION_TYPE_RETRACTED_PUBLICATION = "D016441"
+const PUBLICATION_TYPE_RETRACTION_OF_PUBLICATION = "D016440"
+
+func (article PubmedArticle) GetPublicationTypes() []PublicationType {
 
-	return pubdate
+	if len(article.MedlineCitation.Article) == 0 {
+		return []PublicationType{}
+	}
+	return article.MedlineCitation.Article[0].PublicationTypeList.PublicationTypes
 }
 
-func (article PubmedArticle) IsReview() bool {
+// Returns true if the article has any of the publication types given by UI
+func (article PubmedArticle) HasPublicationType(uis ...string) bool {
 
-	for _, pubtype := range article.MedlineCitation.Article[0].PublicationTypeList.PublicationTypes {
-		if pubtype.Type == "Review" || pubtype.Type == "Systematic Review" {
-			return true
+	for _, pubtype := range article.GetPublicationTypes() {
+		for _, ui := range uis {
+			if pubtype.UI == ui {
+				return true
+			}
 		}
 	}
 	return false
 }
 
-func (article PubmedArticle) IsRetracted() bool {
+func (article PubmedArticle) IsReview() bool {
+	return article.HasPublicationType(PUBLICATION_TYPE_REVIEW, PUBLICATION_TYPE_SYSTEMATIC_REVIEW)
+}
 
-	for _, pubtype := range article.MedlineCitation.Article[0].PublicationTypeList.PublicationTypes {
-		if pubtype.Type == "Retracted Publication" {
-			return true
-		}
-	}
-	return false
+func (article PubmedArticle) IsRetracted() bool {
+	return article.HasPublicationType(PUBLICATION_TYPE_RETRACTED_PUBLICATION)
 }
 
 func (article PubmedArticle) IsRetraction() bool {
-
-	for _, pubtype := range article.MedlineCitation.Article[0].PublicationTypeList.PublicationTypes {
-		if pubtype.Type == "Retraction of Publication" {
-			return true
-		}
-	}
-	return false
+	return article.HasPublicationType(PUBLICATION_TYPE_RETRACTION_OF_PUBLICATION)
 }
 
 func (article PubmedArticle) GetRetractedInPMID() string {
 
-	for _, comment := range article.MedlineCitation.CommentsCorrectionsList.CommentsCorrections {
-		if comment.RefType == "RetractionIn" {
-			return comment.PMID
-		}
+	pmids := article.GetRelatedPMIDs(REF_TYPE_RETRACTION_IN)
+	if len(pmids) == 0 {
+		return ""
 	}
-	return ""
+	return pmids[0]
 }
@@ -17,6 +17,7 @@ package EUtils
 import (
 	"encoding/xml"
 	"os"
+	"strconv"
 	"testing"
 )
 
@@ -71,10 +72,74 @@ func TestGenericExample(t *testing.T) {
 		t.Errorf("Expected article to not be a retraction")
 	}
 
+	pubtypes := article.GetPublicationTypes()
+	if len(pubtypes) != 2 {
+		t.Errorf("Wrong number of publication types: %d", len(pubtypes))
+	}
+	if !article.HasPublicationType("D016428") {
+		t.Errorf("Expected article to be a journal article")
+	}
+	if article.HasPublicationType("D002363", "D016421") {
+		t.Errorf("Expected article to not be a case report or editorial")
+	}
+
 	retracted_in := article.GetRetractedInPMID()
 	if retracted_in != "" {
 		t.Errorf("Got unexpected retraction PMID: %s", retracted_in)
 	}
+
+	pii := article.GetArticleID("pii")
+	if pii != "S0036-46652018005000400" {
+		t.Errorf("Got unexpected pii: %s", pii)
+	}
+
+	doi := article.GetDOI()
+	if doi != "10.1590/S1678-9946201860023" {
+		t.Errorf("Got unexpected DOI: %s", doi)
+	}
+}
+
+func TestDOIFromELocationID(t *testing.T) {
+
+	article := PubmedArticle{
+		MedlineCitation: MedlineCitation{
+			Article: []Article{
+				{
+					ELocationIDs: []ELocationID{
+						{EIdType: "pii", ValidYN: "Y", Value: "e123"},
+						{EIdType: "doi", ValidYN: "N", Value: "10.1000/wrong"},
+						{EIdType: "doi", ValidYN: "Y", Value: "10.1000/right"},
+					},
+				},
+			},
+		},
+	}
+
+	if article.GetDOI() != "10.1000/RIGHT" {
+		t.Errorf("Got unexpected DOI: %s", article.GetDOI())
+	}
+}
+
+func TestNormaliseDOI(t *testing.T) {
+
+	testdata := []struct {
+		Value string
+		DOI   string
+	}{
+		{Value: "10.1590/s1678-9946201860023", DOI: "10.1590/S1678-9946201860023"},
+		{Value: "https://doi.org/10.1038/nphys1170", DOI: "10.1038/NPHYS1170"},
+		{Value: "http://dx.doi.org/10.1038/nphys1170", DOI: "10.1038/NPHYS1170"},
+		{Value: "DOI: 10.1038/nphys1170 ", DOI: "10.1038/NPHYS1170"},
+		{Value: "S0036-46652018005000400", DOI: ""},
+		{Value: "", DOI: ""},
+	}
+
+	for _, data := range testdata {
+		doi := NormaliseDOI(data.Value)
+		if doi != data.DOI {
+			t.Errorf("Expected %q to normalise to %q, got %q", data.Value, data.DOI, doi)
+		}
+	}
 }
 
 func TestDescriptionOnlyMajorTopics(t *testing.T) {
@@ -147,3 +212,36 @@ func TestRetraction(t *testing.T) {
 		t.Errorf("Got unexpected retraction PMID: %s", retracted_in)
 	}
 }
+
+func TestSplitIDs(t *testing.T) {
+
+	testdata := []struct {
+		count      int
+		size       int
+		chunkCount int
+		lastSize   int
+	}{
+		{count: 0, size: 200, chunkCount: 0, lastSize: 0},
+		{count: 1, size: 200, chunkCount: 1, lastSize: 1},
+		{count: 200, size: 200, chunkCount: 1, lastSize: 200},
+		{count: 201, size: 200, chunkCount: 2, lastSize: 1},
+		{count: 450, size: 200, chunkCount: 3, lastSize: 50},
+	}
+
+	for _, testitem := range testdata {
+
+		ids := make([]string, testitem.count)
+		for i := range ids {
+			ids[i] = strconv.Itoa(i)
+		}
+
+		chunks := splitIDs(ids, testitem.size)
+		if len(chunks) != testitem.chunkCount {
+			t.Errorf("Got %d chunks for %d IDs, expected %d", len(chunks), testitem.count, testitem.chunkCount)
+			continue
+		}
+		if len(chunks) > 0 && len(chunks[len(chunks)-1]) != testitem.lastSize {
+			t.Errorf("Last chunk for %d IDs has %d items, expected %d", testitem.count, len(chunks[len(chunks)-1]), testitem.lastSize)
+		}
+	}
+}
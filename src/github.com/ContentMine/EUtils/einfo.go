@@ -0,0 +1,171 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ?db=pubmed&version=2.0&retmode=json
+
+type EInfoField struct {
+	Name          string `json:"name"`
+	FullName      string `json:"fullname"`
+	Description   string `json:"description"`
+	TermCount     string `json:"termcount"`
+	IsDate        string `json:"isdate"`
+	IsNumerical   string `json:"isnumerical"`
+	SingleToken   string `json:"singletoken"`
+	Hierarchy     string `json:"hierarchy"`
+	IsHidden      string `json:"ishidden"`
+	IsTruncatable string `json:"istruncatable"`
+	IsRangable    string `json:"israngable"`
+}
+
+type EInfoLink struct {
+	Name        string `json:"name"`
+	Menu        string `json:"menu"`
+	Description string `json:"description"`
+	DBTo        string `json:"dbto"`
+}
+
+type EInfoDBInfo struct {
+	DBName      string       `json:"dbname"`
+	MenuName    string       `json:"menuname"`
+	Description string       `json:"description"`
+	DBBuild     string       `json:"dbbuild"`
+	Count       string       `json:"count"`
+	LastUpdate  string       `json:"lastupdate"`
+	Fields      []EInfoField `json:"fieldlist"`
+	Links       []EInfoLink  `json:"linklist"`
+}
+
+// NCBI will return the dbinfo as either a single object or a list of objects, so
+// we accept either
+type EInfoDBInfoList []EInfoDBInfo
+
+type EInfoResult struct {
+	DBList []string        `json:"dblist"`
+	DBInfo EInfoDBInfoList `json:"dbinfo"`
+	Error  *string         `json:"ERROR"`
+}
+
+type EInfoResponse struct {
+	Header *ESearchHeader `json:"header"`
+	Result *EInfoResult   `json:"einforesult"`
+
+	Error *string `json:"error"`
+}
+
+// If DB is left empty then NCBI will just return the list of databases it supports.
+type EInfoRequest struct {
+	DB     string
+	APIKey string
+}
+
+func (l *EInfoDBInfoList) UnmarshalJSON(data []byte) error {
+
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		list := make([]EInfoDBInfo, 0)
+		err := json.Unmarshal(data, &list)
+		if err != nil {
+			return err
+		}
+		*l = list
+		return nil
+	}
+
+	info := EInfoDBInfo{}
+	err := json.Unmarshal(data, &info)
+	if err != nil {
+		return err
+	}
+	*l = []EInfoDBInfo{info}
+	return nil
+}
+
+func (e *EInfoRequest) Do() (*EInfoResult, error) {
+	return e.DoWithClient(DefaultClient)
+}
+
+func (e *EInfoRequest) DoWithClient(c *Client) (*EInfoResult, error) {
+	return e.DoWithContext(context.Background(), c)
+}
+
+// Like DoWithClient, but the request is abandoned if the context is cancelled
+func (e *EInfoRequest) DoWithContext(ctx context.Context, c *Client) (*EInfoResult, error) {
+
+	q := url.Values{}
+	if e.DB != "" {
+		q.Add("db", e.DB)
+		q.Add("version", "2.0")
+	}
+	q.Add("retmode", "json")
+
+	var einfo_resp EInfoResponse
+	err := c.retry(ctx, func() error {
+		resp, err := c.call(ctx, "GET", EINFO_ENDPOINT, e.APIKey, q)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		einfo_resp = EInfoResponse{}
+		err = json.NewDecoder(resp.Body).Decode(&einfo_resp)
+		if err != nil {
+			return err
+		}
+
+		if einfo_resp.Error != nil {
+			return newAPIError(*einfo_resp.Error)
+		}
+		if einfo_resp.Result == nil {
+			return fmt.Errorf("API Error: No result returned")
+		}
+		if einfo_resp.Result.Error != nil {
+			return newAPIError(fmt.Sprintf("Info error: %s", *einfo_resp.Result.Error))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return einfo_resp.Result, nil
+}
+
+// Looks up a search field by either its short name (e.g., "MAJR") or its full name (e.g.,
+// "MeSH Major Topic"), ignoring case, as PubMed does when parsing field tags.
+func (info EInfoDBInfo) GetField(tag string) (EInfoField, bool) {
+	for _, field := range info.Fields {
+		if strings.EqualFold(field.Name, tag) || strings.EqualFold(field.FullName, tag) {
+			return field, true
+		}
+	}
+	return EInfoField{}, false
+}
+
+func (info EInfoDBInfo) HasLink(link_name string) bool {
+	for _, link := range info.Links {
+		if link.Name == link_name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestEInfoFields(t *testing.T) {
+
+	f, err := os.Open("testdata/einfo.json")
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+	defer f.Close()
+
+	var resp EInfoResponse
+	err = json.NewDecoder(f).Decode(&resp)
+	if err != nil {
+		t.Fatalf("Failed to decode test data: %v", err)
+	}
+
+	if resp.Result == nil || len(resp.Result.DBInfo) != 1 {
+		t.Fatalf("Expected a single database info in result")
+	}
+	info := resp.Result.DBInfo[0]
+
+	if info.DBName != "pubmed" {
+		t.Errorf("Got unexpected database name: %s", info.DBName)
+	}
+
+	for _, tag := range []string{"Mesh Major Topic", "MAJR", "ptyp", "Publication Type"} {
+		if _, ok := info.GetField(tag); !ok {
+			t.Errorf("Expected to find field %s", tag)
+		}
+	}
+	if _, ok := info.GetField("Mesh Minor Topic"); ok {
+		t.Errorf("Did not expect to find field Mesh Minor Topic")
+	}
+
+	if !info.HasLink(LINKNAME_PUBMED_PUBMED_REFS) {
+		t.Errorf("Expected to find link %s", LINKNAME_PUBMED_PUBMED_REFS)
+	}
+}
+
+func TestEInfoSingleDBInfo(t *testing.T) {
+
+	var result EInfoResult
+	err := json.Unmarshal([]byte(`{"dbinfo":{"dbname":"pmc","fieldlist":[{"name":"ALL","fullname":"All Fields"}]}}`), &result)
+	if err != nil {
+		t.Fatalf("Failed to decode test data: %v", err)
+	}
+	if len(result.DBInfo) != 1 || result.DBInfo[0].DBName != "pmc" {
+		t.Errorf("Got unexpected database info: %v", result.DBInfo)
+	}
+}
@@ -0,0 +1,175 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ?dbfrom=pubmed&db=pubmed&id=29846473&linkname=pubmed_pubmed_refs&cmd=neighbor&retmode=json
+
+const ELINK_CMD_NEIGHBOR = "neighbor"
+const ELINK_CMD_NEIGHBOR_HISTORY = "neighbor_history"
+
+// Papers this paper cites
+const LINKNAME_PUBMED_PUBMED_REFS = "pubmed_pubmed_refs"
+
+// Papers that cite this paper
+const LINKNAME_PUBMED_PUBMED_CITEDIN = "pubmed_pubmed_citedin"
+
+// The PMC record for this PubMed record
+const LINKNAME_PUBMED_PMC = "pubmed_pmc"
+
+type ELinkSetDB struct {
+	DBTo     string   `json:"dbto"`
+	LinkName string   `json:"linkname"`
+	Links    []string `json:"links"`
+}
+
+type ELinkSetDBHistory struct {
+	DBTo     string `json:"dbto"`
+	LinkName string `json:"linkname"`
+	QueryKey string `json:"querykey"`
+}
+
+type ELinkSet struct {
+	DBFrom        string              `json:"dbfrom"`
+	IDs           []string            `json:"ids"`
+	LinkSetDBs    []ELinkSetDB        `json:"linksetdbs"`
+	LinkHistories []ELinkSetDBHistory `json:"linksetdbhistories"`
+	WebEnv        string              `json:"webenv"`
+	Error         *string             `json:"ERROR"`
+}
+
+type ELinkResult struct {
+	Header   *ESearchHeader `json:"header"`
+	LinkSets []ELinkSet     `json:"linksets"`
+
+	Error *string `json:"error"`
+}
+
+type ELinkRequest struct {
+	DBFrom   string
+	DB       string
+	APIKey   string
+	IDs      []string
+	LinkName string
+	Command  string
+}
+
+func (e *ELinkRequest) Do() (*ELinkResult, error) {
+	return e.DoWithClient(DefaultClient)
+}
+
+func (e *ELinkRequest) DoWithClient(c *Client) (*ELinkResult, error) {
+	return e.DoWithContext(context.Background(), c)
+}
+
+// Like DoWithClient, but the request is abandoned if the context is cancelled
+func (e *ELinkRequest) DoWithContext(ctx context.Context, c *Client) (*ELinkResult, error) {
+
+	if len(e.IDs) == 0 {
+		return nil, fmt.Errorf("No IDs provided.")
+	}
+
+	command := e.Command
+	if command == "" {
+		command = ELINK_CMD_NEIGHBOR
+	}
+
+	q := url.Values{}
+	q.Add("dbfrom", e.DBFrom)
+	q.Add("db", e.DB)
+	q.Add("cmd", command)
+	q.Add("retmode", "json")
+	if e.LinkName != "" {
+		q.Add("linkname", e.LinkName)
+	}
+	if command == ELINK_CMD_NEIGHBOR {
+		// Passing each ID as its own parameter gets us a linkset per ID, which is what lets us
+		// map each source ID to its targets. A comma separated list would merge them all.
+		for _, id := range e.IDs {
+			q.Add("id", id)
+		}
+	} else {
+		q.Add("id", strings.Join(e.IDs, ","))
+	}
+
+	var elink_resp ELinkResult
+	err := c.retry(ctx, func() error {
+		resp, err := c.call(ctx, "GET", ELINK_ENDPOINT, e.APIKey, q)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		elink_resp = ELinkResult{}
+		err = json.NewDecoder(resp.Body).Decode(&elink_resp)
+		if err != nil {
+			return err
+		}
+
+		if elink_resp.Error != nil {
+			return newAPIError(*elink_resp.Error)
+		}
+		for _, linkset := range elink_resp.LinkSets {
+			if linkset.Error != nil {
+				return newAPIError(fmt.Sprintf("Link error: %s", *linkset.Error))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &elink_resp, nil
+}
+
+// Returns a map of each source ID to the list of target IDs found for the given link name. Source IDs
+// that had no links will not be present in the map.
+func (r ELinkResult) GetLinks(link_name string) map[string][]string {
+
+	links := make(map[string][]string)
+	for _, linkset := range r.LinkSets {
+		for _, linksetdb := range linkset.LinkSetDBs {
+			if linksetdb.LinkName != link_name {
+				continue
+			}
+			for _, id := range linkset.IDs {
+				links[id] = append(links[id], linksetdb.Links...)
+			}
+		}
+	}
+	return links
+}
+
+// For neighbor_history requests, returns the WebEnv and query key under which NCBI stored the
+// links for the given link name, which can then be passed to EFetchHistoryRequest.
+func (r ELinkResult) GetHistory(link_name string) (string, string, bool) {
+
+	for _, linkset := range r.LinkSets {
+		for _, history := range linkset.LinkHistories {
+			if history.LinkName == link_name {
+				return linkset.WebEnv, history.QueryKey, true
+			}
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,85 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func loadELinkJSON(filename string) (ELinkResult, error) {
+	var result ELinkResult
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return ELinkResult{}, err
+	}
+	defer f.Close()
+
+	err = json.NewDecoder(f).Decode(&result)
+	return result, err
+}
+
+func TestELinkNeighbor(t *testing.T) {
+	result, err := loadELinkJSON("testdata/elink.json")
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+
+	refs := result.GetLinks(LINKNAME_PUBMED_PUBMED_REFS)
+	if len(refs) != 2 {
+		t.Fatalf("Unexpected number of source IDs: %d", len(refs))
+	}
+	if len(refs["29846473"]) != 3 {
+		t.Errorf("Unexpected number of references for 29846473: %d", len(refs["29846473"]))
+	}
+	if len(refs["28405850"]) != 1 || refs["28405850"][0] != "26356201" {
+		t.Errorf("Unexpected references for 28405850: %v", refs["28405850"])
+	}
+
+	citedin := result.GetLinks(LINKNAME_PUBMED_PUBMED_CITEDIN)
+	if len(citedin) != 1 {
+		t.Errorf("Unexpected number of cited by source IDs: %d", len(citedin))
+	}
+
+	pmc := result.GetLinks(LINKNAME_PUBMED_PMC)
+	if len(pmc) != 0 {
+		t.Errorf("Unexpected PMC links: %v", pmc)
+	}
+}
+
+func TestELinkNeighborHistory(t *testing.T) {
+	result, err := loadELinkJSON("testdata/elink_history.json")
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+
+	webenv, query_key, ok := result.GetHistory(LINKNAME_PUBMED_PUBMED_REFS)
+	if !ok {
+		t.Fatalf("Expected history for references")
+	}
+	if webenv != "MCID_5c8a7b6e1b2c3d4e5f607182" {
+		t.Errorf("Got unexpected WebEnv: %s", webenv)
+	}
+	if query_key != "1" {
+		t.Errorf("Got unexpected query key: %s", query_key)
+	}
+
+	_, _, ok = result.GetHistory(LINKNAME_PUBMED_PUBMED_CITEDIN)
+	if ok {
+		t.Errorf("Did not expect history for cited by")
+	}
+}
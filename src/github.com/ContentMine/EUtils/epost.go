@@ -0,0 +1,154 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Used if no chunk size is specified on an EPostRequest
+const EPOST_DEFAULT_CHUNK_SIZE = 5000
+
+type EPostResponse struct {
+	XMLName  xml.Name `xml:"ePostResult"`
+	QueryKey string   `xml:"QueryKey"`
+	WebEnv   string   `xml:"WebEnv"`
+	Error    *string  `xml:"ERROR"`
+}
+
+// All the chunks of a post end up on the same WebEnv, each under its own query key
+type EPostResult struct {
+	WebEnv    string
+	QueryKeys []string
+}
+
+// Uploads a list of IDs to the history server. If WebEnv is set then the IDs are added to that
+// existing environment, otherwise a new one is created.
+type EPostRequest struct {
+	DB        string
+	APIKey    string
+	IDs       []string
+	WebEnv    string
+	ChunkSize int
+}
+
+func (e *EPostRequest) Do() (*EPostResult, error) {
+	return e.DoWithClient(DefaultClient)
+}
+
+func (e *EPostRequest) DoWithClient(c *Client) (*EPostResult, error) {
+	return e.DoWithContext(context.Background(), c)
+}
+
+// Like DoWithClient, but the request is abandoned if the context is cancelled
+func (e *EPostRequest) DoWithContext(ctx context.Context, c *Client) (*EPostResult, error) {
+
+	if len(e.IDs) == 0 {
+		return nil, fmt.Errorf("No IDs provided.")
+	}
+
+	chunk_size := e.ChunkSize
+	if chunk_size <= 0 {
+		chunk_size = EPOST_DEFAULT_CHUNK_SIZE
+	}
+
+	result := EPostResult{
+		WebEnv:    e.WebEnv,
+		QueryKeys: make([]string, 0),
+	}
+
+	for _, chunk := range splitIDs(e.IDs, chunk_size) {
+
+		q := url.Values{}
+		q.Add("db", e.DB)
+		q.Add("id", strings.Join(chunk, ","))
+		if result.WebEnv != "" {
+			q.Add("WebEnv", result.WebEnv)
+		}
+
+		var epost_resp EPostResponse
+		err := c.retry(ctx, func() error {
+			resp, err := c.call(ctx, "POST", EPOST_ENDPOINT, e.APIKey, q)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+
+			epost_resp = EPostResponse{}
+			err = xml.NewDecoder(resp.Body).Decode(&epost_resp)
+			if err != nil {
+				return err
+			}
+
+			if epost_resp.Error != nil {
+				return newAPIError(*epost_resp.Error)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		result.WebEnv = epost_resp.WebEnv
+		result.QueryKeys = append(result.QueryKeys, epost_resp.QueryKey)
+	}
+
+	return &result, nil
+}
+
+// Fetches all the posted records back from the history server, merged into a single article set.
+func (r EPostResult) Fetch(db string, api_key string, batch_size int) (PubmedArticleSet, error) {
+	return r.FetchWithClient(DefaultClient, db, api_key, batch_size)
+}
+
+func (r EPostResult) FetchWithClient(c *Client, db string, api_key string, batch_size int) (PubmedArticleSet, error) {
+	return r.FetchWithContext(context.Background(), c, db, api_key, batch_size)
+}
+
+func (r EPostResult) FetchWithContext(ctx context.Context, c *Client, db string, api_key string, batch_size int) (PubmedArticleSet, error) {
+
+	if batch_size <= 0 {
+		batch_size = EFETCH_DEFAULT_CHUNK_SIZE
+	}
+
+	result := PubmedArticleSet{Articles: make([]PubmedArticle, 0)}
+
+	for _, query_key := range r.QueryKeys {
+		for i := 0; ; i += batch_size {
+			fetch_request := EFetchHistoryRequest{
+				DB:       db,
+				WebEnv:   r.WebEnv,
+				QueryKey: query_key,
+				APIKey:   api_key,
+				RetStart: i,
+				RetMax:   batch_size,
+			}
+			fetch_resp, err := fetch_request.DoWithContext(ctx, c)
+			if err != nil {
+				return PubmedArticleSet{}, err
+			}
+			result.Articles = append(result.Articles, fetch_resp.Articles...)
+			if len(fetch_resp.Articles) < batch_size {
+				break
+			}
+		}
+	}
+
+	return result, nil
+}
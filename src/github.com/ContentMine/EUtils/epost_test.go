@@ -0,0 +1,52 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestEPostResponse(t *testing.T) {
+
+	var resp EPostResponse
+	err := xml.Unmarshal([]byte(`<?xml version="1.0" encoding="UTF-8" ?>
+<!DOCTYPE ePostResult PUBLIC "-//NLM//DTD epost 20090526//EN" "https://eutils.ncbi.nlm.nih.gov/eutils/dtd/20090526/epost.dtd">
+<ePostResult>
+	<QueryKey>1</QueryKey>
+	<WebEnv>MCID_5c8a7b6e1b2c3d4e5f607182</WebEnv>
+</ePostResult>`), &resp)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Error != nil {
+		t.Errorf("Unexpected error: %s", *resp.Error)
+	}
+	if resp.QueryKey != "1" {
+		t.Errorf("Got unexpected query key: %s", resp.QueryKey)
+	}
+	if resp.WebEnv != "MCID_5c8a7b6e1b2c3d4e5f607182" {
+		t.Errorf("Got unexpected WebEnv: %s", resp.WebEnv)
+	}
+
+	var error_resp EPostResponse
+	err = xml.Unmarshal([]byte(`<ePostResult><ERROR>IDs contain invalid characters</ERROR></ePostResult>`), &error_resp)
+	if err != nil {
+		t.Fatalf("Failed to decode error response: %v", err)
+	}
+	if error_resp.Error == nil || *error_resp.Error != "IDs contain invalid characters" {
+		t.Errorf("Expected error in response")
+	}
+}
@@ -15,14 +15,13 @@
 package EUtils
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"net/url"
+	"strings"
 )
 
-const ESEARCH_URL string = "https://eutils.ncbi.nlm.nih.gov/entrez/eutils/esearch.fcgi"
-
 // ?db=pubmed&term=food[MeSH%20Major%20Topic]&reldate=60&datetype=edat&retmax=100&usehistory=y&retmode=json
 
 type ESearchHeader struct {
@@ -35,11 +34,25 @@ type ESearchTranslationSetItem struct {
 	To   string `json:"to"`
 }
 
+// The translation stack is a mix of search terms and the operators that join them (e.g., "AND",
+// "GROUP"), so an item will either have Operator set or the term details.
 type ESearchTranslationStackItem struct {
-	Term    string `json:"term"`
-	Field   string `json:"field"`
-	Count   string `json:"count"`
-	Explode string `json:"explode"`
+	Term     string `json:"term"`
+	Field    string `json:"field"`
+	Count    string `json:"count"`
+	Explode  string `json:"explode"`
+	Operator string `json:"-"`
+}
+
+type ESearchErrorList struct {
+	PhrasesNotFound []string `json:"phrasesnotfound"`
+	FieldsNotFound  []string `json:"fieldsnotfound"`
+}
+
+type ESearchWarningList struct {
+	PhrasesIgnored        []string `json:"phrasesignored"`
+	QuotedPhrasesNotFound []string `json:"quotedphrasesnotfound"`
+	OutputMessages        []string `json:"outputmessages"`
 }
 
 type ESearchResult struct {
@@ -51,8 +64,10 @@ type ESearchResult struct {
 	WebEnv           string                        `json:"webenv"`
 	IDs              []string                      `json:"idlist"`
 	TranslationSet   []ESearchTranslationSetItem   `json:"translationset"`
-	TranslationStack []ESearchTranslationStackItem `json:"translationstrack"`
+	TranslationStack []ESearchTranslationStackItem `json:"translationstack"`
 	QueryTranslation string                        `json:"querytranslation"`
+	ErrorList        *ESearchErrorList             `json:"errorlist"`
+	WarningList      *ESearchWarningList           `json:"warninglist"`
 }
 
 type ESearchResponse struct {
@@ -63,6 +78,8 @@ type ESearchResponse struct {
 	Count *string `json:"count"`
 }
 
+// MinDate and MaxDate restrict the search to a range of dates, of the type given by DateType
+// (e.g., "pdat" for publication date). If used, both dates must be set, in the form YYYY/MM/DD.
 type ESearchRequest struct {
 	DB         string
 	APIKey     string
@@ -70,6 +87,41 @@ type ESearchRequest struct {
 	RetMax     int
 	RetStart   int
 	UseHistory bool
+	MinDate    string
+	MaxDate    string
+	DateType   string
+}
+
+func (i *ESearchTranslationStackItem) UnmarshalJSON(data []byte) error {
+
+	var operator string
+	if json.Unmarshal(data, &operator) == nil {
+		*i = ESearchTranslationStackItem{Operator: operator}
+		return nil
+	}
+
+	// Use an alias type so we don't recurse back into this method
+	type stackItem ESearchTranslationStackItem
+	var item stackItem
+	err := json.Unmarshal(data, &item)
+	if err != nil {
+		return err
+	}
+	*i = ESearchTranslationStackItem(item)
+	return nil
+}
+
+// Returns the terms from the translation stack that PubMed matched against the given field, e.g.,
+// "MeSH Major Topic". Field names are compared ignoring case.
+func (r ESearchResult) GetTranslatedTerms(field string) []ESearchTranslationStackItem {
+
+	items := make([]ESearchTranslationStackItem, 0)
+	for _, item := range r.TranslationStack {
+		if item.Operator == "" && strings.EqualFold(item.Field, field) {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 func (r ESearchResponse) String() string {
@@ -88,18 +140,17 @@ func (r ESearchResponse) String() string {
 }
 
 func (e *ESearchRequest) Do() (*ESearchResult, error) {
+	return e.DoWithClient(DefaultClient)
+}
 
-	if e.APIKey == "" {
-		return nil, fmt.Errorf("No API Key provided.")
-	}
+func (e *ESearchRequest) DoWithClient(c *Client) (*ESearchResult, error) {
+	return e.DoWithContext(context.Background(), c)
+}
 
-	req, err := http.NewRequest("GET", ESEARCH_URL, nil)
-	if err != nil {
-		return nil, err
-	}
+// Like DoWithClient, but the request is abandoned if the context is cancelled
+func (e *ESearchRequest) DoWithContext(ctx context.Context, c *Client) (*ESearchResult, error) {
 
-	q := req.URL.Query()
-	q.Add("api_key", e.APIKey)
+	q := url.Values{}
 	q.Add("term", e.Term)
 	q.Add("db", e.DB)
 	q.Add("retmode", "json")
@@ -112,39 +163,42 @@ func (e *ESearchRequest) Do() (*ESearchResult, error) {
 	if e.RetStart > 0 {
 		q.Add("retstart", fmt.Sprintf("%d", e.RetStart))
 	}
-	req.URL.RawQuery = q.Encode()
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	if e.MinDate != "" || e.MaxDate != "" {
+		q.Add("mindate", e.MinDate)
+		q.Add("maxdate", e.MaxDate)
+	}
+	if e.DateType != "" {
+		q.Add("datetype", e.DateType)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, err := ioutil.ReadAll(resp.Body)
+	var esearch_resp ESearchResponse
+	err := c.retry(ctx, func() error {
+		resp, err := c.call(ctx, "GET", ESEARCH_ENDPOINT, e.APIKey, q)
 		if err != nil {
-			return nil, fmt.Errorf("Status code %d", resp.StatusCode)
-		} else {
-			return nil, fmt.Errorf("Status code %d: %s", resp.StatusCode, body)
+			return err
 		}
-	}
+		defer resp.Body.Close()
 
-	esearch_resp := ESearchResponse{}
-	err = json.NewDecoder(resp.Body).Decode(&esearch_resp)
+		esearch_resp = ESearchResponse{}
+		err = json.NewDecoder(resp.Body).Decode(&esearch_resp)
+		if err != nil {
+			return err
+		}
+
+		if esearch_resp.Error != nil {
+			return newAPIError(*esearch_resp.Error)
+		}
+		if esearch_resp.Result == nil {
+			return fmt.Errorf("API Error: No result returned %v", esearch_resp)
+		}
+		if esearch_resp.Result.Error != nil {
+			return newAPIError(fmt.Sprintf("Search error: %s", *(esearch_resp.Result.Error)))
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if esearch_resp.Error != nil {
-		return nil, fmt.Errorf("API Error: %s", *esearch_resp.Error)
-	}
-	if esearch_resp.Result == nil {
-		return nil, fmt.Errorf("API Error: No result returned %v", esearch_resp)
-	}
-	if esearch_resp.Result.Error != nil {
-		return nil, fmt.Errorf("Search error: %s", *(esearch_resp.Result.Error))
-	}
-
 	return esearch_resp.Result, nil
 }
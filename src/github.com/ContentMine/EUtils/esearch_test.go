@@ -0,0 +1,81 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func loadESearchJSON(filename string) (ESearchResponse, error) {
+	var resp ESearchResponse
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return ESearchResponse{}, err
+	}
+	defer f.Close()
+
+	err = json.NewDecoder(f).Decode(&resp)
+	return resp, err
+}
+
+func TestESearchTranslationStack(t *testing.T) {
+	resp, err := loadESearchJSON("testdata/esearch.json")
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+	if resp.Result == nil {
+		t.Fatalf("Expected result in response")
+	}
+
+	if len(resp.Result.TranslationStack) != 6 {
+		t.Fatalf("Unexpected translation stack length: %d", len(resp.Result.TranslationStack))
+	}
+	if resp.Result.TranslationStack[3].Operator != "OR" {
+		t.Errorf("Expected operator in stack, got %v", resp.Result.TranslationStack[3])
+	}
+
+	mesh := resp.Result.GetTranslatedTerms("Mesh Major Topic")
+	if len(mesh) != 1 {
+		t.Fatalf("Unexpected number of MeSH terms: %d", len(mesh))
+	}
+	if mesh[0].Count != "5627" {
+		t.Errorf("Got unexpected count for MeSH term: %s", mesh[0].Count)
+	}
+
+	ptyp := resp.Result.GetTranslatedTerms("ptyp")
+	if len(ptyp) != 2 {
+		t.Errorf("Unexpected number of publication type terms: %d", len(ptyp))
+	}
+}
+
+func TestESearchNotFound(t *testing.T) {
+	resp, err := loadESearchJSON("testdata/esearch_notfound.json")
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+	if resp.Result == nil {
+		t.Fatalf("Expected result in response")
+	}
+
+	if len(resp.Result.GetTranslatedTerms("Mesh Major Topic")) != 0 {
+		t.Errorf("Did not expect MeSH terms in translation")
+	}
+	if resp.Result.ErrorList == nil || len(resp.Result.ErrorList.PhrasesNotFound) != 1 {
+		t.Errorf("Expected phrase not found in error list")
+	}
+}
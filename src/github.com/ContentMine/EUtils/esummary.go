@@ -0,0 +1,240 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ?db=pubmed&id=29846473,28405850&version=2.0&retmode=json
+
+// The format NCBI uses for the dates in the docsum history list, e.g., "2018/05/31 06:00"
+const ESUMMARY_HISTORY_DATE_FORMAT = "2006/01/02 15:04"
+
+type ESummaryAuthor struct {
+	Name      string `json:"name"`
+	AuthType  string `json:"authtype"`
+	ClusterID string `json:"clusterid"`
+}
+
+type ESummaryArticleID struct {
+	IDType  string `json:"idtype"`
+	IDTypeN int    `json:"idtypen"`
+	Value   string `json:"value"`
+}
+
+type ESummaryHistory struct {
+	PubStatus string `json:"pubstatus"`
+	Date      string `json:"date"`
+}
+
+type ESummaryDocSum struct {
+	UID             string              `json:"uid"`
+	Error           *string             `json:"error"`
+	PubDate         string              `json:"pubdate"`
+	EPubDate        string              `json:"epubdate"`
+	Source          string              `json:"source"`
+	Authors         []ESummaryAuthor    `json:"authors"`
+	LastAuthor      string              `json:"lastauthor"`
+	Title           string              `json:"title"`
+	SortTitle       string              `json:"sorttitle"`
+	Volume          string              `json:"volume"`
+	Issue           string              `json:"issue"`
+	Pages           string              `json:"pages"`
+	Languages       []string            `json:"lang"`
+	NLMUniqueID     string              `json:"nlmuniqueid"`
+	ISSN            string              `json:"issn"`
+	ESSN            string              `json:"essn"`
+	PubTypes        []string            `json:"pubtype"`
+	RecordStatus    string              `json:"recordstatus"`
+	PubStatus       string              `json:"pubstatus"`
+	ArticleIDs      []ESummaryArticleID `json:"articleids"`
+	History         []ESummaryHistory   `json:"history"`
+	Attributes      []string            `json:"attributes"`
+	FullJournalName string              `json:"fulljournalname"`
+	ELocationID     string              `json:"elocationid"`
+	DocType         string              `json:"doctype"`
+	SortPubDate     string              `json:"sortpubdate"`
+	SortFirstAuthor string              `json:"sortfirstauthor"`
+}
+
+// The result block in an esummary reply is a map of the list of UIDs and then each UID to its docsum, so
+// we have to unpack it by hand. We keep the docsums in the order NCBI listed the UIDs.
+type ESummaryResult struct {
+	UIDs    []string
+	DocSums []ESummaryDocSum
+}
+
+type ESummaryResponse struct {
+	Header *ESearchHeader  `json:"header"`
+	Result *ESummaryResult `json:"result"`
+
+	Error *string `json:"error"`
+}
+
+type ESummaryRequest struct {
+	DB       string
+	APIKey   string
+	IDs      []string
+	WebEnv   string
+	QueryKey string
+	RetMax   int
+	RetStart int
+}
+
+func (r *ESummaryResult) UnmarshalJSON(data []byte) error {
+
+	raw := make(map[string]json.RawMessage)
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	uids, ok := raw["uids"]
+	if !ok {
+		return fmt.Errorf("No uids list in esummary result")
+	}
+	err = json.Unmarshal(uids, &r.UIDs)
+	if err != nil {
+		return err
+	}
+
+	r.DocSums = make([]ESummaryDocSum, 0, len(r.UIDs))
+	for _, uid := range r.UIDs {
+		raw_docsum, ok := raw[uid]
+		if !ok {
+			return fmt.Errorf("No docsum for uid %s in esummary result", uid)
+		}
+		docsum := ESummaryDocSum{}
+		err = json.Unmarshal(raw_docsum, &docsum)
+		if err != nil {
+			return err
+		}
+		r.DocSums = append(r.DocSums, docsum)
+	}
+
+	return nil
+}
+
+func (r ESummaryResult) GetDocSum(uid string) (ESummaryDocSum, bool) {
+	for _, docsum := range r.DocSums {
+		if docsum.UID == uid {
+			return docsum, true
+		}
+	}
+	return ESummaryDocSum{}, false
+}
+
+func (e *ESummaryRequest) Do() (*ESummaryResult, error) {
+	return e.DoWithClient(DefaultClient)
+}
+
+func (e *ESummaryRequest) DoWithClient(c *Client) (*ESummaryResult, error) {
+	return e.DoWithContext(context.Background(), c)
+}
+
+// Like DoWithClient, but the request is abandoned if the context is cancelled
+func (e *ESummaryRequest) DoWithContext(ctx context.Context, c *Client) (*ESummaryResult, error) {
+
+	if len(e.IDs) == 0 && (e.WebEnv == "" || e.QueryKey == "") {
+		return nil, fmt.Errorf("Either IDs or WebEnv and QueryKey must be provided.")
+	}
+
+	q := url.Values{}
+	q.Add("db", e.DB)
+	q.Add("version", "2.0")
+	q.Add("retmode", "json")
+	if len(e.IDs) > 0 {
+		q.Add("id", strings.Join(e.IDs, ","))
+	} else {
+		q.Add("WebEnv", e.WebEnv)
+		q.Add("query_key", e.QueryKey)
+	}
+	if e.RetMax > 0 {
+		q.Add("retmax", fmt.Sprintf("%d", e.RetMax))
+	}
+	if e.RetStart > 0 {
+		q.Add("retstart", fmt.Sprintf("%d", e.RetStart))
+	}
+
+	var esummary_resp ESummaryResponse
+	err := c.retry(ctx, func() error {
+		resp, err := c.call(ctx, "GET", ESUMMARY_ENDPOINT, e.APIKey, q)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		esummary_resp = ESummaryResponse{}
+		err = json.NewDecoder(resp.Body).Decode(&esummary_resp)
+		if err != nil {
+			return err
+		}
+
+		if esummary_resp.Error != nil {
+			return newAPIError(*esummary_resp.Error)
+		}
+		if esummary_resp.Result == nil {
+			return fmt.Errorf("API Error: No result returned")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return esummary_resp.Result, nil
+}
+
+func (docsum ESummaryDocSum) GetArticleID(id_type string) string {
+	for _, article_id := range docsum.ArticleIDs {
+		if article_id.IDType == id_type {
+			return article_id.Value
+		}
+	}
+	return ""
+}
+
+func (docsum ESummaryDocSum) GetPMCID() string {
+	return strings.TrimPrefix(docsum.GetArticleID("pmc"), "PMC")
+}
+
+func (docsum ESummaryDocSum) HasPublicationType(pubtype string) bool {
+	for _, t := range docsum.PubTypes {
+		if t == pubtype {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the date for a given status in the history, such as "received", "accepted", "pubmed", etc.
+func (docsum ESummaryDocSum) GetHistoryDate(status string) (time.Time, bool) {
+	for _, history := range docsum.History {
+		if history.PubStatus == status {
+			t, err := time.Parse(ESUMMARY_HISTORY_DATE_FORMAT, history.Date)
+			if err != nil {
+				return time.Time{}, false
+			}
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
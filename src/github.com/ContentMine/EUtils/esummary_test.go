@@ -0,0 +1,87 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func loadESummaryJSON(filename string) (ESummaryResponse, error) {
+	var resp ESummaryResponse
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return ESummaryResponse{}, err
+	}
+	defer f.Close()
+
+	err = json.NewDecoder(f).Decode(&resp)
+	return resp, err
+}
+
+func TestESummaryDocSum(t *testing.T) {
+	resp, err := loadESummaryJSON("testdata/esummary.json")
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+
+	if resp.Result == nil {
+		t.Fatalf("Expected result in response")
+	}
+	if len(resp.Result.DocSums) != 1 {
+		t.Fatalf("Unexpected number of docsums: %d", len(resp.Result.DocSums))
+	}
+
+	docsum, ok := resp.Result.GetDocSum("29846473")
+	if !ok {
+		t.Fatalf("Failed to find docsum by UID")
+	}
+
+	if docsum.Title != "Pathology and pathogenesis of human leptospirosis: a commented review." {
+		t.Errorf("Got unexpected title: %s", docsum.Title)
+	}
+
+	pmcid := docsum.GetPMCID()
+	if pmcid != "5975557" {
+		t.Errorf("Got unexpected PMCID for docsum: %s", pmcid)
+	}
+
+	doi := docsum.GetArticleID("doi")
+	if doi != "10.1590/s1678-9946201860023" {
+		t.Errorf("Got unexpected DOI for docsum: %s", doi)
+	}
+
+	if !docsum.HasPublicationType("Review") {
+		t.Errorf("Expected docsum to be review")
+	}
+
+	if len(docsum.Authors) != 3 {
+		t.Errorf("Wrong number of authors: %d", len(docsum.Authors))
+	}
+
+	received, ok := docsum.GetHistoryDate("received")
+	if !ok {
+		t.Errorf("Expected received date in history")
+	} else if received.Year() != 2018 || received.Month() != 1 || received.Day() != 4 {
+		t.Errorf("Got unexpected received date: %v", received)
+	}
+
+	_, ok = docsum.GetHistoryDate("revised")
+	if ok {
+		t.Errorf("Did not expect revised date in history")
+	}
+}
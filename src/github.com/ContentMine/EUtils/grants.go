@@ -0,0 +1,54 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// CompleteYN is "N" for older records, where NLM only recorded some of the grants
+type GrantList struct {
+	XMLName    xml.Name `xml:"GrantList"`
+	CompleteYN string   `xml:"CompleteYN,attr"`
+	Grants     []Grant  `xml:"Grant"`
+}
+
+// Agency is the name of the funder as NLM write it, e.g., "NIGMS NIH HHS" or "Wellcome Trust".
+// Acronym is only given for NIH institutes.
+type Grant struct {
+	XMLName xml.Name `xml:"Grant"`
+	GrantID string   `xml:"GrantID"`
+	Acronym string   `xml:"Acronym"`
+	Agency  string   `xml:"Agency"`
+	Country string   `xml:"Country"`
+}
+
+// Returns the grants that funded the article, leaving out any that don't say who the funder was
+func (article PubmedArticle) GetGrants() []Grant {
+
+	grants := make([]Grant, 0)
+	if len(article.MedlineCitation.Article) == 0 {
+		return grants
+	}
+	for _, grant := range article.MedlineCitation.Article[0].GrantList.Grants {
+		grant.GrantID = strings.TrimSpace(grant.GrantID)
+		grant.Agency = strings.TrimSpace(grant.Agency)
+		if grant.Agency != "" {
+			grants = append(grants, grant)
+		}
+	}
+	return grants
+}
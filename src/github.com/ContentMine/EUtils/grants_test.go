@@ -0,0 +1,62 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"testing"
+)
+
+func TestGetGrants(t *testing.T) {
+	article_set, err := loadXML("testdata/grants.xml")
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+	article := article_set.Articles[0]
+
+	if article.MedlineCitation.Article[0].GrantList.CompleteYN != "N" {
+		t.Errorf("Expected grant list to be incomplete")
+	}
+
+	grants := article.GetGrants()
+
+	testdata := []Grant{
+		{GrantID: "R01 GM123456", Acronym: "GM", Agency: "NIGMS NIH HHS", Country: "United States"},
+		{GrantID: "098051", Agency: "Wellcome Trust", Country: "United Kingdom"},
+		{GrantID: "", Agency: "Wellcome Trust", Country: "United Kingdom"},
+	}
+
+	if len(grants) != len(testdata) {
+		t.Fatalf("Expected %d grants, got %d", len(testdata), len(grants))
+	}
+
+	for idx, data := range testdata {
+		grant := grants[idx]
+		if grant.GrantID != data.GrantID || grant.Acronym != data.Acronym || grant.Agency != data.Agency || grant.Country != data.Country {
+			t.Errorf("Grant %d: expected %v, got %v", idx, data, grant)
+		}
+	}
+}
+
+func TestGetGrantsNone(t *testing.T) {
+	article_set, err := loadXML("testdata/example1.xml")
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+
+	grants := article_set.Articles[0].GetGrants()
+	if len(grants) != 0 {
+		t.Errorf("Expected no grants, got %d", len(grants))
+	}
+}
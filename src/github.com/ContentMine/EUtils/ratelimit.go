@@ -0,0 +1,100 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// The request rates NCBI allow by default. Institutions can negotiate higher limits, in which
+// case set Client.RequestsPerSecond.
+const NCBI_REQUESTS_PER_SECOND_WITH_KEY = 10.0
+const NCBI_REQUESTS_PER_SECOND_WITHOUT_KEY = 3.0
+
+// A token bucket rate limiter that is safe to share between goroutines. The bucket holds a single
+// token, so requests are spaced out evenly rather than allowed to burst, as NCBI count requests
+// over any one second window.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func NewRateLimiter(requests_per_second float64) *RateLimiter {
+	return &RateLimiter{
+		rate:   requests_per_second,
+		tokens: 1.0,
+		last:   time.Now(),
+	}
+}
+
+// Blocks until the caller is allowed to make a request. Each caller reserves its token before
+// sleeping, so concurrent callers queue up behind one another rather than all waking at once.
+func (l *RateLimiter) Wait() {
+	time.Sleep(l.reserve())
+}
+
+// Like Wait, but gives up if the context is cancelled first, in which case the context's error is
+// returned and the reserved token is handed back.
+func (l *RateLimiter) WaitContext(ctx context.Context) error {
+
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens += 1.0
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (l *RateLimiter) reserve() time.Duration {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return 0
+	}
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > 1.0 {
+		l.tokens = 1.0
+	}
+	l.last = now
+
+	l.tokens -= 1.0
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
@@ -0,0 +1,101 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterSpacing(t *testing.T) {
+
+	limiter := NewRateLimiter(20.0)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		limiter.Wait()
+	}
+	elapsed := time.Since(start)
+
+	// The first request goes straight through, the other four should be 50ms apart
+	if elapsed < 190*time.Millisecond {
+		t.Errorf("Requests were not rate limited, took %v", elapsed)
+	}
+	if elapsed > 500*time.Millisecond {
+		t.Errorf("Requests were limited more than expected, took %v", elapsed)
+	}
+}
+
+func TestRateLimiterConcurrent(t *testing.T) {
+
+	limiter := NewRateLimiter(20.0)
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limiter.Wait()
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	if elapsed < 190*time.Millisecond {
+		t.Errorf("Concurrent requests were not rate limited, took %v", elapsed)
+	}
+}
+
+func TestRateLimiterWaitContextCancelled(t *testing.T) {
+
+	limiter := NewRateLimiter(1.0)
+	limiter.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := limiter.WaitContext(ctx)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Wait was not cut short by the context, took %v", time.Since(start))
+	}
+}
+
+func TestClientLimiterRates(t *testing.T) {
+
+	client := NewClient("")
+
+	if client.limiter("key").rate != NCBI_REQUESTS_PER_SECOND_WITH_KEY {
+		t.Errorf("Expected keyed rate, got %f", client.limiter("key").rate)
+	}
+	if client.limiter("").rate != NCBI_REQUESTS_PER_SECOND_WITHOUT_KEY {
+		t.Errorf("Expected unkeyed rate, got %f", client.limiter("").rate)
+	}
+	if client.limiter("key") != client.limiter("key") {
+		t.Errorf("Expected limiter to be shared for the same key")
+	}
+
+	client = NewClient("")
+	client.RequestsPerSecond = 50.0
+	if client.limiter("key").rate != 50.0 {
+		t.Errorf("Expected configured rate, got %f", client.limiter("key").rate)
+	}
+}
@@ -0,0 +1,67 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// The works an article cites, as deposited with PMC. Reference lists can be split into titled
+// sections, in which case they are nested.
+type ReferenceList struct {
+	XMLName        xml.Name        `xml:"ReferenceList"`
+	Title          string          `xml:"Title"`
+	References     []Reference     `xml:"Reference"`
+	ReferenceLists []ReferenceList `xml:"ReferenceList"`
+}
+
+type Reference struct {
+	XMLName       xml.Name      `xml:"Reference"`
+	Citation      string        `xml:"Citation"`
+	ArticleIDList ArticleIdList `xml:"ArticleIdList"`
+}
+
+// Returns all the references the article cites, in the order they are listed, with any nested
+// lists flattened out.
+func (article PubmedArticle) GetReferences() []Reference {
+
+	references := make([]Reference, 0)
+	for _, list := range article.PubMedData.ReferenceLists {
+		references = list.appendReferences(references)
+	}
+	return references
+}
+
+func (list ReferenceList) appendReferences(references []Reference) []Reference {
+
+	references = append(references, list.References...)
+	for _, nested := range list.ReferenceLists {
+		references = nested.appendReferences(references)
+	}
+	return references
+}
+
+func (ref Reference) GetPMID() string {
+	return ref.ArticleIDList.GetID("pubmed")
+}
+
+func (ref Reference) GetPMCID() string {
+	return strings.TrimPrefix(ref.ArticleIDList.GetID("pmc"), "PMC")
+}
+
+func (ref Reference) GetDOI() string {
+	return NormaliseDOI(ref.ArticleIDList.GetID("doi"))
+}
@@ -0,0 +1,104 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestGetReferences(t *testing.T) {
+
+	testdata := []struct {
+		filename      string
+		count         int
+		firstPMID     string
+		firstCitation string
+	}{
+		{filename: "testdata/example1.xml", count: 45, firstPMID: "20186328", firstCitation: "PLoS Negl Trop Dis. 2010 Feb 23;4(2):e612"},
+		{filename: "testdata/retracted.xml", count: 42, firstPMID: "17108127", firstCitation: "Cancer Res. 2006 Nov 15;66(22):10902-10"},
+		{filename: "testdata/topics.xml", count: 47, firstPMID: "16493403", firstCitation: "Trop Biomed. 2004 Dec;21(2):113-9"},
+		{filename: "testdata/retraction.xml", count: 0},
+	}
+
+	for _, data := range testdata {
+		article_set, err := loadXML(data.filename)
+		if err != nil {
+			t.Errorf("Failed to load test data: %v", err)
+			continue
+		}
+
+		references := article_set.Articles[0].GetReferences()
+		if len(references) != data.count {
+			t.Errorf("%s: expected %d references, got %d", data.filename, data.count, len(references))
+			continue
+		}
+		if data.count == 0 {
+			continue
+		}
+		if references[0].Citation != data.firstCitation {
+			t.Errorf("%s: unexpected first citation %s", data.filename, references[0].Citation)
+		}
+		if references[0].GetPMID() != data.firstPMID {
+			t.Errorf("%s: unexpected first PMID %s", data.filename, references[0].GetPMID())
+		}
+		for idx, ref := range references {
+			if ref.GetPMID() == "" {
+				t.Errorf("%s: reference %d has no PMID", data.filename, idx)
+			}
+		}
+	}
+}
+
+func TestNestedReferenceLists(t *testing.T) {
+
+	data := `<PubmedData>
+		<ReferenceList>
+			<Reference>
+				<Citation>First</Citation>
+				<ArticleIdList>
+					<ArticleId IdType="doi">10.1000/first</ArticleId>
+					<ArticleId IdType="pmc">PMC123</ArticleId>
+				</ArticleIdList>
+			</Reference>
+			<ReferenceList>
+				<Title>Supplementary</Title>
+				<Reference>
+					<Citation>Second</Citation>
+				</Reference>
+			</ReferenceList>
+		</ReferenceList>
+	</PubmedData>`
+
+	article := PubmedArticle{}
+	err := xml.Unmarshal([]byte(data), &article.PubMedData)
+	if err != nil {
+		t.Fatalf("Failed to parse test data: %v", err)
+	}
+
+	references := article.GetReferences()
+	if len(references) != 2 {
+		t.Fatalf("Expected 2 references, got %d", len(references))
+	}
+	if references[0].GetDOI() != "10.1000/FIRST" {
+		t.Errorf("Unexpected DOI: %s", references[0].GetDOI())
+	}
+	if references[0].GetPMCID() != "123" {
+		t.Errorf("Unexpected PMCID: %s", references[0].GetPMCID())
+	}
+	if references[1].Citation != "Second" || references[1].GetPMID() != "" {
+		t.Errorf("Unexpected second reference: %v", references[1])
+	}
+}
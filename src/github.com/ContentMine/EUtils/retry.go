@@ -0,0 +1,210 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How many times we'll retry a request that failed for a transient reason, if the client was made
+// with NewClient
+const DEFAULT_MAX_RETRIES = 5
+
+// The delay before the first retry, which then doubles on each subsequent retry up to the maximum
+const RETRY_BASE_DELAY = 500 * time.Millisecond
+const RETRY_MAX_DELAY = 30 * time.Second
+
+// Error is returned when NCBI reports a failure, either through the HTTP status code or in the
+// body of the reply. Retryable is set if the failure was likely transient, such as hitting the
+// rate limit or a gateway timing out, and so the request may succeed if tried again later.
+type Error struct {
+	StatusCode int
+	Message    string
+	Retryable  bool
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.StatusCode != 0 && e.StatusCode != http.StatusOK {
+		return fmt.Sprintf("Status code %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("API Error: %s", e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Returns true if the error was caused by a failure that may go away if the request is retried
+func IsRetryable(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.Retryable
+}
+
+// These are the messages NCBI put in the body of a reply for failures that are on their side,
+// rather than a problem with the request
+var RETRYABLE_ERROR_MESSAGES = []string{
+	"api rate limit exceeded",
+	"search backend failed",
+	"temporarily unavailable",
+	"unable to obtain query",
+	"timed out",
+	"timeout",
+}
+
+func isRetryableMessage(message string) bool {
+	lower := strings.ToLower(message)
+	for _, m := range RETRYABLE_ERROR_MESSAGES {
+		if strings.Contains(lower, m) {
+			return true
+		}
+	}
+	return false
+}
+
+func isRetryableStatus(status_code int) bool {
+	switch status_code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Builds an error from a message NCBI returned in the body of a successful reply
+func newAPIError(message string) *Error {
+	return &Error{
+		StatusCode: http.StatusOK,
+		Message:    message,
+		Retryable:  isRetryableMessage(message),
+	}
+}
+
+// Builds an error from a reply with a failure status code. NCBI put the reason in the body as
+// either JSON or XML depending on the endpoint and retmode, so we try to dig it out of either.
+func newStatusError(resp *http.Response, body []byte) *Error {
+
+	message := parseErrorBody(body)
+	if message == "" {
+		message = strings.TrimSpace(string(body))
+	}
+
+	return &Error{
+		StatusCode: resp.StatusCode,
+		Message:    message,
+		Retryable:  isRetryableStatus(resp.StatusCode) || isRetryableMessage(message),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// Extracts the error message from an NCBI error reply, which will be either JSON of the form
+// {"error":"API rate limit exceeded",...} or XML with an <ERROR> element under the root element.
+// Returns an empty string if no message could be found.
+func parseErrorBody(body []byte) string {
+
+	trimmed := strings.TrimSpace(string(body))
+
+	if strings.HasPrefix(trimmed, "{") {
+		json_error := struct {
+			Error      string `json:"error"`
+			UpperError string `json:"ERROR"`
+		}{}
+		if json.Unmarshal(body, &json_error) == nil {
+			if json_error.Error != "" {
+				return json_error.Error
+			}
+			return json_error.UpperError
+		}
+	}
+
+	if strings.HasPrefix(trimmed, "<") {
+		xml_error := struct {
+			Error string `xml:"ERROR"`
+		}{}
+		if xml.Unmarshal(body, &xml_error) == nil {
+			return strings.TrimSpace(xml_error.Error)
+		}
+	}
+
+	return ""
+}
+
+// Retry-After is either a number of seconds or an HTTP date
+func parseRetryAfter(value string) time.Duration {
+
+	if value == "" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	t, err := http.ParseTime(value)
+	if err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// Exponential backoff with jitter, so that if several clients get rate limited at the same time
+// they don't all come back at the same time
+func retryDelay(base time.Duration, attempt int, retry_after time.Duration) time.Duration {
+
+	delay := base << uint(attempt)
+	if delay > RETRY_MAX_DELAY || delay <= 0 {
+		delay = RETRY_MAX_DELAY
+	}
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+
+	if retry_after > delay {
+		delay = retry_after
+	}
+	return delay
+}
+
+// Calls f until it either succeeds, returns an error that isn't retryable, we run out of retries,
+// or the context is cancelled.
+func (c *Client) retry(ctx context.Context, f func() error) error {
+
+	attempt := 0
+	for {
+		err := f()
+		if err == nil || !IsRetryable(err) || attempt >= c.MaxRetries || ctx.Err() != nil {
+			return err
+		}
+
+		base := c.RetryBaseDelay
+		if base <= 0 {
+			base = RETRY_BASE_DELAY
+		}
+		timer := time.NewTimer(retryDelay(base, attempt, err.(*Error).RetryAfter))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		attempt += 1
+	}
+}
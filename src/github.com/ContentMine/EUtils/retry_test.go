@@ -0,0 +1,246 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type failingReply struct {
+	status  int
+	headers map[string]string
+	body    string
+}
+
+// Serves the given failures in order, and then the test data file for every request after that
+func newFailingServer(t *testing.T, failures []failingReply, filename string, count *int) *httptest.Server {
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idx := *count
+		*count += 1
+
+		if idx < len(failures) {
+			for k, v := range failures[idx].headers {
+				w.Header().Set(k, v)
+			}
+			w.WriteHeader(failures[idx].status)
+			w.Write([]byte(failures[idx].body))
+			return
+		}
+
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Errorf("Failed to load test data: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(data)
+	}))
+}
+
+func newTestClient(url string) *Client {
+	client := NewClient("test-key")
+	client.BaseURL = url
+	client.RequestsPerSecond = 1000.0
+	client.RetryBaseDelay = time.Millisecond
+	return client
+}
+
+func TestRetryTransientFailures(t *testing.T) {
+
+	failures := []failingReply{
+		{status: http.StatusTooManyRequests, headers: map[string]string{"Retry-After": "0"}, body: `{"error":"API rate limit exceeded","api-key":"test-key","count":"11","limit":"10"}`},
+		{status: http.StatusBadGateway, body: "<html><body>Bad Gateway</body></html>"},
+		{status: http.StatusOK, body: `{"error":"API rate limit exceeded","api-key":"test-key","count":"11","limit":"10"}`},
+		{status: http.StatusOK, body: `{"header":{"type":"esearch","version":"0.3"},"esearchresult":{"ERROR":"Search Backend failed: Exception"}}`},
+	}
+	count := 0
+	server := newFailingServer(t, failures, "testdata/esearch.json", &count)
+	defer server.Close()
+
+	search_request := ESearchRequest{DB: "pubmed", Term: "Leptospirosis"}
+	result, err := search_request.DoWithClient(newTestClient(server.URL))
+	if err != nil {
+		t.Fatalf("Expected search to succeed after retries: %v", err)
+	}
+	if result.Count != "219" {
+		t.Errorf("Got unexpected count: %s", result.Count)
+	}
+	if count != 5 {
+		t.Errorf("Expected 5 requests, got %d", count)
+	}
+}
+
+func TestRetryXMLErrorBody(t *testing.T) {
+
+	failures := []failingReply{
+		{status: http.StatusOK, body: `<?xml version="1.0" ?><eFetchResult><ERROR>Unable to obtain query #1</ERROR></eFetchResult>`},
+	}
+	count := 0
+	server := newFailingServer(t, failures, "testdata/example1.xml", &count)
+	defer server.Close()
+
+	fetch_request := EFetchHistoryRequest{DB: "pubmed", WebEnv: "webenv", QueryKey: "1"}
+	result, err := fetch_request.DoWithClient(newTestClient(server.URL))
+	if err != nil {
+		t.Fatalf("Expected fetch to succeed after retries: %v", err)
+	}
+	if len(result.Articles) != 1 {
+		t.Errorf("Unexpected number of articles: %d", len(result.Articles))
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 requests, got %d", count)
+	}
+}
+
+func TestRetryPermanentFailure(t *testing.T) {
+
+	failures := []failingReply{
+		{status: http.StatusBadRequest, body: `{"error":"Invalid query"}`},
+	}
+	count := 0
+	server := newFailingServer(t, failures, "testdata/esearch.json", &count)
+	defer server.Close()
+
+	search_request := ESearchRequest{DB: "pubmed", Term: "Leptospirosis"}
+	_, err := search_request.DoWithClient(newTestClient(server.URL))
+	if err == nil {
+		t.Fatalf("Expected search to fail")
+	}
+	if IsRetryable(err) {
+		t.Errorf("Did not expect error to be retryable: %v", err)
+	}
+	e, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("Expected an EUtils error, got %T", err)
+	}
+	if e.StatusCode != http.StatusBadRequest || e.Message != "Invalid query" {
+		t.Errorf("Got unexpected error details: %d %s", e.StatusCode, e.Message)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 request, got %d", count)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+
+	failures := make([]failingReply, 10)
+	for i := range failures {
+		failures[i] = failingReply{status: http.StatusServiceUnavailable, body: "Service Unavailable"}
+	}
+	count := 0
+	server := newFailingServer(t, failures, "testdata/esearch.json", &count)
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.MaxRetries = 2
+
+	search_request := ESearchRequest{DB: "pubmed", Term: "Leptospirosis"}
+	_, err := search_request.DoWithClient(client)
+	if err == nil {
+		t.Fatalf("Expected search to fail")
+	}
+	if !IsRetryable(err) {
+		t.Errorf("Expected final error to be retryable: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 requests, got %d", count)
+	}
+}
+
+func TestRetryTimeout(t *testing.T) {
+
+	data, err := ioutil.ReadFile("testdata/esearch.json")
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+
+	// The first request hangs past the client's timeout, the second replies straight away
+	count := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count += 1
+		if count == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.Timeout = 50 * time.Millisecond
+
+	search_request := ESearchRequest{DB: "pubmed", Term: "Leptospirosis"}
+	_, err = search_request.DoWithClient(client)
+	if err != nil {
+		t.Fatalf("Expected search to succeed after timing out once: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 requests, got %d", count)
+	}
+}
+
+func TestRetryCancelled(t *testing.T) {
+
+	failures := make([]failingReply, 10)
+	for i := range failures {
+		failures[i] = failingReply{status: http.StatusServiceUnavailable, body: "Service Unavailable"}
+	}
+	count := 0
+	server := newFailingServer(t, failures, "testdata/esearch.json", &count)
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.RetryBaseDelay = 10 * time.Second
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	search_request := ESearchRequest{DB: "pubmed", Term: "Leptospirosis"}
+	_, err := search_request.DoWithContext(ctx, client)
+	if err != context.Canceled {
+		t.Errorf("Expected search to be cancelled, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Cancelling did not stop the retries, took %v", time.Since(start))
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 request, got %d", count)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+
+	if parseRetryAfter("") != 0 {
+		t.Errorf("Expected no delay for missing header")
+	}
+	if parseRetryAfter("3") != 3*time.Second {
+		t.Errorf("Expected three second delay, got %v", parseRetryAfter("3"))
+	}
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	delay := parseRetryAfter(future)
+	if delay < 50*time.Second || delay > time.Minute {
+		t.Errorf("Got unexpected delay for date: %v", delay)
+	}
+}
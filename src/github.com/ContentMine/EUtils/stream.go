@@ -0,0 +1,127 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"os"
+)
+
+// Walks a PubMed XML document token by token, decoding one article at a time, so that memory use
+// stays flat no matter how many articles are in the document. Works on anything that is a
+// PubmedArticleSet, be it an efetch reply or a file on disk.
+type PubmedArticleDecoder struct {
+	decoder *xml.Decoder
+	closer  io.Closer
+}
+
+func NewPubmedArticleDecoder(r io.Reader) *PubmedArticleDecoder {
+	return &PubmedArticleDecoder{
+		decoder: xml.NewDecoder(r),
+	}
+}
+
+// Opens a local PubMed XML file for decoding. The caller must Close the decoder when done.
+func OpenPubmedArticleFile(filename string) (*PubmedArticleDecoder, error) {
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	d := NewPubmedArticleDecoder(f)
+	d.closer = f
+	return d, nil
+}
+
+// Returns the next article in the document, or io.EOF once there are no more.
+func (d *PubmedArticleDecoder) Next() (PubmedArticle, error) {
+
+	for {
+		token, err := d.decoder.Token()
+		if err != nil {
+			return PubmedArticle{}, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "PubmedArticle":
+			article := PubmedArticle{}
+			err = d.decoder.DecodeElement(&article, &start)
+			return article, err
+		case "PubmedBookArticle":
+			// We don't model books, so skip over them
+			err = d.decoder.Skip()
+			if err != nil {
+				return PubmedArticle{}, err
+			}
+		case "ERROR":
+			// NCBI sometimes put errors in the reply rather than the articles we asked for
+			var message string
+			err = d.decoder.DecodeElement(&message, &start)
+			if err != nil {
+				return PubmedArticle{}, err
+			}
+			return PubmedArticle{}, newAPIError(message)
+		}
+	}
+}
+
+func (d *PubmedArticleDecoder) Close() error {
+	if d.closer != nil {
+		return d.closer.Close()
+	}
+	return nil
+}
+
+// Like Do, but rather than reading all the articles into memory at once, returns a decoder that
+// reads them from the reply as they are asked for. The caller must Close the decoder when done.
+//
+// Only failures in getting a reply are retried, any error reading the reply will be returned by
+// the decoder.
+func (e *EFetchHistoryRequest) Stream() (*PubmedArticleDecoder, error) {
+	return e.StreamWithClient(DefaultClient)
+}
+
+func (e *EFetchHistoryRequest) StreamWithClient(c *Client) (*PubmedArticleDecoder, error) {
+	return e.StreamWithContext(context.Background(), c)
+}
+
+// Like StreamWithClient, but reading from the decoder will fail once the context is cancelled. If
+// the client has a Timeout then it covers reading the whole reply, not just getting it.
+func (e *EFetchHistoryRequest) StreamWithContext(ctx context.Context, c *Client) (*PubmedArticleDecoder, error) {
+
+	var d *PubmedArticleDecoder
+	err := c.retry(ctx, func() error {
+		resp, err := c.call(ctx, "GET", EFETCH_ENDPOINT, e.APIKey, e.params())
+		if err != nil {
+			return err
+		}
+		d = NewPubmedArticleDecoder(resp.Body)
+		d.closer = resp.Body
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
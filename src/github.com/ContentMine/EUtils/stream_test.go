@@ -0,0 +1,118 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStreamFile(t *testing.T) {
+
+	for _, filename := range []string{"testdata/example1.xml", "testdata/topics.xml", "testdata/retracted.xml", "testdata/retraction.xml"} {
+
+		d, err := OpenPubmedArticleFile(filename)
+		if err != nil {
+			t.Errorf("Failed to open test data: %v", err)
+			continue
+		}
+
+		article_set, err := loadXML(filename)
+		if err != nil {
+			t.Errorf("Failed to load test data: %v", err)
+			continue
+		}
+
+		article, err := d.Next()
+		if err != nil {
+			t.Errorf("Failed to decode article from %s: %v", filename, err)
+		} else if article.GetPMID() != article_set.Articles[0].GetPMID() {
+			t.Errorf("Got unexpected PMID %s from %s", article.GetPMID(), filename)
+		}
+
+		_, err = d.Next()
+		if err != io.EOF {
+			t.Errorf("Expected end of file after one article in %s, got %v", filename, err)
+		}
+
+		d.Close()
+	}
+}
+
+func TestStreamMultipleArticles(t *testing.T) {
+
+	doc := `<?xml version="1.0" ?>
+<PubmedArticleSet>
+<PubmedArticle><MedlineCitation><PMID Version="1">1</PMID></MedlineCitation></PubmedArticle>
+<PubmedBookArticle><BookDocument><PMID Version="1">2</PMID></BookDocument></PubmedBookArticle>
+<PubmedArticle><MedlineCitation><PMID Version="1">3</PMID></MedlineCitation></PubmedArticle>
+</PubmedArticleSet>`
+
+	d := NewPubmedArticleDecoder(strings.NewReader(doc))
+	pmids := make([]string, 0)
+	for {
+		article, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to decode article: %v", err)
+		}
+		pmids = append(pmids, article.GetPMID())
+	}
+
+	if len(pmids) != 2 || pmids[0] != "1" || pmids[1] != "3" {
+		t.Errorf("Got unexpected articles: %v", pmids)
+	}
+}
+
+func TestStreamErrorDocument(t *testing.T) {
+
+	d := NewPubmedArticleDecoder(strings.NewReader(`<eFetchResult><ERROR>Unable to obtain query #1</ERROR></eFetchResult>`))
+	_, err := d.Next()
+	if err == nil {
+		t.Fatalf("Expected error from error document")
+	}
+	if !IsRetryable(err) {
+		t.Errorf("Expected error to be retryable: %v", err)
+	}
+}
+
+func TestStreamFetch(t *testing.T) {
+
+	requests := make([]*http.Request, 0)
+	server := newTestServer(t, EFETCH_ENDPOINT, "testdata/retracted.xml", &requests)
+	defer server.Close()
+
+	fetch_request := EFetchHistoryRequest{DB: "pubmed", WebEnv: "webenv", QueryKey: "1", RetMax: 200}
+	d, err := fetch_request.StreamWithClient(newTestClient(server.URL))
+	if err != nil {
+		t.Fatalf("Failed to start fetch: %v", err)
+	}
+	defer d.Close()
+
+	article, err := d.Next()
+	if err != nil {
+		t.Fatalf("Failed to decode article: %v", err)
+	}
+	if article.GetPMID() != "27685632" {
+		t.Errorf("Got unexpected PMID: %s", article.GetPMID())
+	}
+	if requests[0].Form.Get("retmax") != "200" {
+		t.Errorf("Expected retmax to be passed, got %s", requests[0].Form.Get("retmax"))
+	}
+}
@@ -0,0 +1,95 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+type ChemicalList struct {
+	XMLName   xml.Name   `xml:"ChemicalList"`
+	Chemicals []Chemical `xml:"Chemical"`
+}
+
+// RegistryNumber is the CAS or EC number for the substance, or "0" if it doesn't have one
+type Chemical struct {
+	XMLName         xml.Name        `xml:"Chemical"`
+	RegistryNumber  string          `xml:"RegistryNumber"`
+	NameOfSubstance NameOfSubstance `xml:"NameOfSubstance"`
+}
+
+// UI is either a MeSH descriptor (D-prefixed) or a supplementary concept (C-prefixed)
+type NameOfSubstance struct {
+	XMLName xml.Name `xml:"NameOfSubstance"`
+	Name    string   `xml:",chardata"`
+	UI      string   `xml:"UI,attr"`
+}
+
+type SupplMeshList struct {
+	XMLName        xml.Name        `xml:"SupplMeshList"`
+	SupplMeshNames []SupplMeshName `xml:"SupplMeshName"`
+}
+
+// Type is one of "Disease", "Protocol", or "Organism"
+type SupplMeshName struct {
+	XMLName xml.Name `xml:"SupplMeshName"`
+	Type    string   `xml:"Type,attr"`
+	UI      string   `xml:"UI,attr"`
+	Name    string   `xml:",chardata"`
+}
+
+// Supplementary concepts are the more specific terms, such as individual compounds and rare
+// diseases, that NLM record alongside MeSH headings. Each is mapped to one or more headings.
+func IsSupplementaryConceptID(ui string) bool {
+	return strings.HasPrefix(ui, "C")
+}
+
+func (article PubmedArticle) GetChemicals() []Chemical {
+	return article.MedlineCitation.ChemicalList.Chemicals
+}
+
+func (article PubmedArticle) GetSupplementaryConcepts() []SupplMeshName {
+	return article.MedlineCitation.SupplMeshList.SupplMeshNames
+}
+
+// Returns the substances and supplementary concepts the article was indexed with that aren't
+// already among its MeSH headings. These include anything the article mentions using, such as
+// reagents and buffers, so they're candidates for a human to consider as main subjects rather
+// than main subjects in their own right. Each is only listed once.
+func (article PubmedArticle) GetSubstanceTopics() []MeshDescriptorName {
+
+	seen := make(map[string]bool)
+	for _, mesh := range article.MedlineCitation.MeshHeadingList.MeshHeadings {
+		seen[mesh.DescriptorName.MeshID] = true
+	}
+
+	topics := make([]MeshDescriptorName, 0)
+	add := func(ui string, name string) {
+		if ui == "" || seen[ui] {
+			return
+		}
+		seen[ui] = true
+		topics = append(topics, MeshDescriptorName{Name: strings.TrimSpace(name), MeshID: ui})
+	}
+
+	for _, chemical := range article.GetChemicals() {
+		add(chemical.NameOfSubstance.UI, chemical.NameOfSubstance.Name)
+	}
+	for _, concept := range article.GetSupplementaryConcepts() {
+		add(concept.UI, concept.Name)
+	}
+	return topics
+}
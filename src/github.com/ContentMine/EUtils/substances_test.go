@@ -0,0 +1,68 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"testing"
+)
+
+func TestGetSubstances(t *testing.T) {
+	article_set, err := loadXML("testdata/retracted.xml")
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+	article := article_set.Articles[0]
+
+	chemicals := article.GetChemicals()
+	if len(chemicals) == 0 {
+		t.Fatalf("Expected chemicals")
+	}
+	if chemicals[0].NameOfSubstance.UI != "C000623205" || chemicals[0].NameOfSubstance.Name != "MIRN623 microRNA, human" {
+		t.Errorf("Unexpected first chemical: %v", chemicals[0])
+	}
+	if chemicals[2].RegistryNumber != "EC 3.4.24.24" {
+		t.Errorf("Unexpected registry number: %s", chemicals[2].RegistryNumber)
+	}
+
+	concepts := article.GetSupplementaryConcepts()
+	if len(concepts) != 1 || concepts[0].UI != "C538231" || concepts[0].Type != "Disease" || concepts[0].Name != "Adenocarcinoma of lung" {
+		t.Errorf("Unexpected supplementary concepts: %v", concepts)
+	}
+
+	topics := article.GetSubstanceTopics()
+	ids := make(map[string]bool)
+	for _, topic := range topics {
+		if ids[topic.MeshID] {
+			t.Errorf("Topic %s listed more than once", topic.MeshID)
+		}
+		ids[topic.MeshID] = true
+	}
+	if len(topics) != 2 || !ids["C000623205"] || !ids["C538231"] {
+		t.Errorf("Expected supplementary concepts as topics, got %v", topics)
+	}
+	if ids["D035683"] {
+		t.Errorf("Expected chemicals already in the MeSH headings to be left out, got %v", topics)
+	}
+}
+
+func TestIsSupplementaryConceptID(t *testing.T) {
+
+	if !IsSupplementaryConceptID("C538231") {
+		t.Errorf("Expected C538231 to be a supplementary concept")
+	}
+	if IsSupplementaryConceptID("D000077192") {
+		t.Errorf("Expected D000077192 to not be a supplementary concept")
+	}
+}
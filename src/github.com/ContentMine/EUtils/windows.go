@@ -0,0 +1,217 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"time"
+)
+
+// PubMed will only let you page through the first 10,000 records of a search result, even when
+// using the history server: any retstart past 9,999 is rejected.
+const ESEARCH_MAX_RECORDS = 10000
+
+// The format NCBI expect for mindate and maxdate
+const ESEARCH_DATE_FORMAT = "2006/01/02"
+
+const DATE_TYPE_PUBLICATION = "pdat"
+const DATE_TYPE_ENTREZ = "edat"
+const DATE_TYPE_MODIFICATION = "mdat"
+
+// PubMed has records going back to the 1700s, so if no start date is given this is early enough to
+// cover everything
+const ESEARCH_EARLIEST_DATE = "1700/01/01"
+
+type dateWindow struct {
+	min time.Time
+	max time.Time
+}
+
+// Runs a search as a series of date windows, each small enough that all its records can be paged
+// through. The search is first run as is, and if that has too many results it is split in two by
+// date, and each half split again in turn until every window fits.
+//
+// A window of a single day can not be split any further, so if a single day has more than
+// ESEARCH_MAX_RECORDS results then that window is returned as is, and only the first
+// ESEARCH_MAX_RECORDS of it can be fetched.
+type ESearchIterator struct {
+	ctx      context.Context
+	client   *Client
+	request  ESearchRequest
+	pending  []dateWindow
+	started  bool
+	finished bool
+}
+
+func (e *ESearchRequest) Windows() *ESearchIterator {
+	return e.WindowsWithClient(DefaultClient)
+}
+
+func (e *ESearchRequest) WindowsWithClient(c *Client) *ESearchIterator {
+	return e.WindowsWithContext(context.Background(), c)
+}
+
+// Like WindowsWithClient, but every search made by the iterator is abandoned once the context is
+// cancelled
+func (e *ESearchRequest) WindowsWithContext(ctx context.Context, c *Client) *ESearchIterator {
+	return &ESearchIterator{
+		ctx:     ctx,
+		client:  c,
+		request: *e,
+	}
+}
+
+// Returns the search result for the next window, or io.EOF once all windows have been returned.
+// Windows are returned oldest first.
+func (it *ESearchIterator) Next() (*ESearchResult, error) {
+
+	if it.finished {
+		return nil, io.EOF
+	}
+
+	if !it.started {
+		it.started = true
+
+		result, err := it.request.DoWithContext(it.ctx, it.client)
+		if err != nil {
+			return nil, err
+		}
+		fits, err := resultFits(result)
+		if err != nil {
+			return nil, err
+		}
+		if fits {
+			it.finished = true
+			return result, nil
+		}
+
+		window, err := it.initialWindow()
+		if err != nil {
+			return nil, err
+		}
+		it.pending = []dateWindow{window}
+	}
+
+	for len(it.pending) > 0 {
+		window := it.pending[len(it.pending)-1]
+		it.pending = it.pending[:len(it.pending)-1]
+
+		request := it.request
+		request.MinDate = window.min.Format(ESEARCH_DATE_FORMAT)
+		request.MaxDate = window.max.Format(ESEARCH_DATE_FORMAT)
+		if request.DateType == "" {
+			request.DateType = DATE_TYPE_PUBLICATION
+		}
+
+		result, err := request.DoWithContext(it.ctx, it.client)
+		if err != nil {
+			return nil, err
+		}
+		fits, err := resultFits(result)
+		if err != nil {
+			return nil, err
+		}
+
+		if fits || !window.max.After(window.min) {
+			return result, nil
+		}
+
+		// Split the window, pushing the later half first so we work through the dates in order
+		days := int(window.max.Sub(window.min).Hours() / 24)
+		mid := window.min.AddDate(0, 0, days/2)
+		it.pending = append(it.pending,
+			dateWindow{min: mid.AddDate(0, 0, 1), max: window.max},
+			dateWindow{min: window.min, max: mid})
+	}
+
+	it.finished = true
+	return nil, io.EOF
+}
+
+func (it *ESearchIterator) initialWindow() (dateWindow, error) {
+
+	min_date := it.request.MinDate
+	if min_date == "" {
+		min_date = ESEARCH_EARLIEST_DATE
+	}
+	min, err := time.Parse(ESEARCH_DATE_FORMAT, min_date)
+	if err != nil {
+		return dateWindow{}, err
+	}
+
+	var max time.Time
+	if it.request.MaxDate != "" {
+		max, err = time.Parse(ESEARCH_DATE_FORMAT, it.request.MaxDate)
+		if err != nil {
+			return dateWindow{}, err
+		}
+	} else {
+		// Publication dates can be in the future for articles published online ahead of print
+		max = time.Date(time.Now().Year()+1, 12, 31, 0, 0, 0, 0, time.UTC)
+	}
+
+	return dateWindow{min: min, max: max}, nil
+}
+
+func resultFits(result *ESearchResult) (bool, error) {
+	count, err := strconv.Atoi(result.Count)
+	if err != nil {
+		return false, err
+	}
+	return count <= ESEARCH_MAX_RECORDS, nil
+}
+
+// Returns every ID that matches the search, working around the record limit by splitting the
+// search into date windows. IDs are de-duplicated, and returned in the order they were first seen.
+func (e *ESearchRequest) AllIDs() ([]string, error) {
+	return e.AllIDsWithClient(DefaultClient)
+}
+
+func (e *ESearchRequest) AllIDsWithClient(c *Client) ([]string, error) {
+	return e.AllIDsWithContext(context.Background(), c)
+}
+
+func (e *ESearchRequest) AllIDsWithContext(ctx context.Context, c *Client) ([]string, error) {
+
+	request := *e
+	request.UseHistory = false
+	request.RetStart = 0
+	request.RetMax = ESEARCH_MAX_RECORDS
+
+	ids := make([]string, 0)
+	seen := make(map[string]bool)
+
+	windows := request.WindowsWithContext(ctx, c)
+	for {
+		result, err := windows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range result.IDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return ids, nil
+}
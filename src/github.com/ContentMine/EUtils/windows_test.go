@@ -0,0 +1,208 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package EUtils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Pretends to be esearch for a database with per_day records published on each day from start
+// for the given number of days. Each record's ID is made from the day it was published.
+func newWindowServer(t *testing.T, start time.Time, days int, per_day int) *httptest.Server {
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		first, last := 0, days-1
+		if q.Get("mindate") != "" {
+			if q.Get("datetype") != DATE_TYPE_PUBLICATION {
+				t.Errorf("Expected datetype %s, got %s", DATE_TYPE_PUBLICATION, q.Get("datetype"))
+			}
+			min, err := time.Parse(ESEARCH_DATE_FORMAT, q.Get("mindate"))
+			if err != nil {
+				t.Errorf("Bad mindate %s", q.Get("mindate"))
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			max, err := time.Parse(ESEARCH_DATE_FORMAT, q.Get("maxdate"))
+			if err != nil {
+				t.Errorf("Bad maxdate %s", q.Get("maxdate"))
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			first = int(min.Sub(start).Hours() / 24)
+			last = int(max.Sub(start).Hours() / 24)
+			if first < 0 {
+				first = 0
+			}
+			if last > days-1 {
+				last = days - 1
+			}
+		}
+
+		count := 0
+		if last >= first {
+			count = (last - first + 1) * per_day
+		}
+
+		retmax, _ := strconv.Atoi(q.Get("retmax"))
+		ids := make([]string, 0)
+		for day := first; day <= last && len(ids) < retmax; day++ {
+			for i := 0; i < per_day && len(ids) < retmax; i++ {
+				ids = append(ids, fmt.Sprintf("%d", day*per_day+i))
+			}
+		}
+
+		resp := ESearchResponse{
+			Result: &ESearchResult{
+				Count:    fmt.Sprintf("%d", count),
+				IDs:      ids,
+				WebEnv:   "TESTENV",
+				QueryKey: fmt.Sprintf("%d-%d", first, last),
+			},
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestWindowsSmallSearch(t *testing.T) {
+
+	start := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := newWindowServer(t, start, 10, 100)
+	defer server.Close()
+
+	search_request := ESearchRequest{DB: "pubmed", Term: "test", RetMax: 1, UseHistory: true}
+	windows := search_request.WindowsWithClient(newTestClient(server.URL))
+
+	result, err := windows.Next()
+	if err != nil {
+		t.Fatalf("Failed to get first window: %v", err)
+	}
+	if result.Count != "1000" {
+		t.Errorf("Expected the whole search in one window, got %s", result.Count)
+	}
+
+	_, err = windows.Next()
+	if err != io.EOF {
+		t.Errorf("Expected EOF after a single window, got %v", err)
+	}
+}
+
+func TestWindowsSplitSearch(t *testing.T) {
+
+	start := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := newWindowServer(t, start, 300, 100)
+	defer server.Close()
+
+	search_request := ESearchRequest{DB: "pubmed", Term: "test", RetMax: 1, UseHistory: true}
+	windows := search_request.WindowsWithClient(newTestClient(server.URL))
+
+	total := 0
+	last_day := -1
+	for {
+		result, err := windows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to get window: %v", err)
+		}
+
+		count, _ := strconv.Atoi(result.Count)
+		if count > ESEARCH_MAX_RECORDS {
+			t.Errorf("Window %s has %d records, more than the limit", result.QueryKey, count)
+		}
+		total += count
+
+		var first, last int
+		fmt.Sscanf(result.QueryKey, "%d-%d", &first, &last)
+		if count > 0 && first <= last_day {
+			t.Errorf("Windows out of order or overlapping: %s after day %d", result.QueryKey, last_day)
+		}
+		if count > 0 {
+			last_day = last
+		}
+	}
+
+	if total != 30000 {
+		t.Errorf("Expected windows to cover 30000 records, got %d", total)
+	}
+}
+
+func TestWindowsSingleDayOverLimit(t *testing.T) {
+
+	start := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := newWindowServer(t, start, 1, 20000)
+	defer server.Close()
+
+	search_request := ESearchRequest{
+		DB:       "pubmed",
+		Term:     "test",
+		RetMax:   1,
+		MinDate:  "2010/01/01",
+		MaxDate:  "2010/01/02",
+		DateType: DATE_TYPE_PUBLICATION,
+	}
+	windows := search_request.WindowsWithClient(newTestClient(server.URL))
+
+	over := 0
+	for {
+		result, err := windows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to get window: %v", err)
+		}
+		if result.Count == "20000" {
+			over += 1
+		}
+	}
+
+	if over != 1 {
+		t.Errorf("Expected the single day to be returned once despite being over the limit, got %d", over)
+	}
+}
+
+func TestAllIDs(t *testing.T) {
+
+	start := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := newWindowServer(t, start, 250, 100)
+	defer server.Close()
+
+	search_request := ESearchRequest{DB: "pubmed", Term: "test"}
+	ids, err := search_request.AllIDsWithClient(newTestClient(server.URL))
+	if err != nil {
+		t.Fatalf("Failed to get IDs: %v", err)
+	}
+
+	if len(ids) != 25000 {
+		t.Errorf("Expected 25000 IDs, got %d", len(ids))
+	}
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if seen[id] {
+			t.Errorf("ID %s returned more than once", id)
+		}
+		seen[id] = true
+	}
+}
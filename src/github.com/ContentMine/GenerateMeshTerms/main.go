@@ -25,7 +25,8 @@ import (
 	"os"
 	"sort"
 	"strings"
-	"time"
+
+	"github.com/ContentMine/EUtils"
 )
 
 type FeedTerm struct {
@@ -211,12 +212,18 @@ func main() {
 
 	log.Printf("MeSH ID count: %d", len(meshid_set))
 
+	// The NCBI docs ask in general that you don't make request more frequently than three a second without
+	// an API key. I'm not sure that this end point is covered under that (as I suspect this is a static page)
+	// but just incase...
+	limiter := EUtils.NewRateLimiter(EUtils.NCBI_REQUESTS_PER_SECOND_WITHOUT_KEY)
+
 	for mesh_id, _ := range meshid_set {
 
 		if mesh_id == "NoID" {
 			continue
 		}
 
+		limiter.Wait()
 		label, err := getMeshLabel(mesh_id)
 		if err != nil {
 			log.Printf("Error looking up %s, skipping: %v.", mesh_id, err)
@@ -224,10 +231,6 @@ func main() {
 		}
 
 		meshid_set[mesh_id] = label
-
-		// The NCBI docs ask in general that you don't make request more frequently than ten a second. I'm not
-		// sure that this end point is covered under that (as I suspect this is a static page) but just incase...
-		time.Sleep(500 * time.Millisecond)
 	}
 
 	label_list := make([]string, 0, len(meshid_set))
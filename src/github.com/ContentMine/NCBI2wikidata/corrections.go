@@ -0,0 +1,271 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ContentMine/EUtils"
+)
+
+// A statement already on wikidata, as far as we need to know it to tell whether it's wrong. We only
+// look at item values, so Value is an item ID, or empty if NoValue is set. Referenced is whether
+// anyone has given a source for it.
+type ExistingStatement struct {
+	Value      string
+	NoValue    bool
+	Referenced bool
+}
+
+// The statements for each item, by property
+type ExistingStatements map[string]map[string][]ExistingStatement
+
+func (e ExistingStatements) Get(item string, property_id string) []ExistingStatement {
+	return e[item][property_id]
+}
+
+// Deprecated statements are already marked as wrong, so we leave them out. A novalue statement
+// has no ps: value, just a type saying it's novalue, and somevalue statements have a blank node as
+// their value, which we skip later.
+const STATEMENTS_QUERY = `SELECT ?item ?value ?novalue (COUNT(?ref) AS ?refs) WHERE {
+  VALUES ?item { %s }
+  ?item p:%s ?statement.
+  ?statement wikibase:rank ?rank.
+  FILTER(?rank != wikibase:DeprecatedRank)
+  OPTIONAL { ?statement ps:%s ?value. }
+  BIND(EXISTS { ?statement a wdno:%s. } AS ?novalue)
+  OPTIONAL { ?statement prov:wasDerivedFrom ?ref. }
+}
+GROUP BY ?item ?value ?novalue
+`
+
+type statementBinding struct {
+	Item    Result `json:"item"`
+	Value   Result `json:"value"`
+	NoValue Result `json:"novalue"`
+	Refs    Result `json:"refs"`
+}
+
+type statementResponse struct {
+	Results struct {
+		Bindings []statementBinding `json:"bindings"`
+	} `json:"results"`
+}
+
+const WIKIDATA_ENTITY_PREFIX = "http://www.wikidata.org/entity/"
+
+func buildStatementsSparqlQuery(items []string, property_id string) string {
+
+	values := make([]string, len(items))
+	for idx, item := range items {
+		values[idx] = "wd:" + item
+	}
+	return fmt.Sprintf(STATEMENTS_QUERY, strings.Join(values, " "), property_id, property_id, property_id)
+}
+
+// Gets the current statements for the given properties on each of the items
+func GetStatementsFromWikiData(ctx context.Context, items []string, property_ids []string) (ExistingStatements, error) {
+
+	statements := make(ExistingStatements)
+
+	for _, property_id := range property_ids {
+		for i := 0; i < len(items); i += MAX_ITEMS_PER_QUERY {
+			j := i + MAX_ITEMS_PER_QUERY
+			if len(items) < j {
+				j = len(items)
+			}
+
+			data := statementResponse{}
+			err := fetchSparqlResults(ctx, buildStatementsSparqlQuery(items[i:j], property_id), &data)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, binding := range data.Results.Bindings {
+				item := strings.TrimPrefix(binding.Item.Value, WIKIDATA_ENTITY_PREFIX)
+				statement := ExistingStatement{NoValue: binding.NoValue.Value == "true"}
+				if !statement.NoValue {
+					if !strings.HasPrefix(binding.Value.Value, WIKIDATA_ENTITY_PREFIX) {
+						continue
+					}
+					statement.Value = strings.TrimPrefix(binding.Value.Value, WIKIDATA_ENTITY_PREFIX)
+				}
+				refs, _ := strconv.Atoi(binding.Refs.Value)
+				statement.Referenced = refs > 0
+
+				if statements[item] == nil {
+					statements[item] = make(map[string][]ExistingStatement)
+				}
+				statements[item][property_id] = append(statements[item][property_id], statement)
+			}
+		}
+	}
+
+	return statements, nil
+}
+
+// What we think should be done about a statement on wikidata that disagrees with PubMed. Statements
+// nobody has given a source for we can just remove, but if someone has sourced a statement, or
+// explicitly said there is no value, it's better to deprecate it so the disagreement is kept. As
+// QuickStatements can't change ranks, deprecations have to be made by hand.
+const CORRECTION_REMOVE = "remove"
+const CORRECTION_DEPRECATE = "deprecate"
+
+type Correction struct {
+	Item     string
+	Property string
+	Current  ExistingStatement
+	Expected []string
+	Action   string
+	StatedIn string
+}
+
+// Compares the statements on wikidata for a property with the values we got from PubMed, and
+// proposes a correction for each one that isn't among them
+func findCorrections(item string, property_id string, expected []string, existing []ExistingStatement, stated_in string) []Correction {
+
+	corrections := make([]Correction, 0)
+	for _, statement := range existing {
+		if !statement.NoValue && containsItem(expected, statement.Value) {
+			continue
+		}
+		action := CORRECTION_REMOVE
+		if statement.NoValue || statement.Referenced {
+			action = CORRECTION_DEPRECATE
+		}
+		corrections = append(corrections, Correction{
+			Item:     item,
+			Property: property_id,
+			Current:  statement,
+			Expected: expected,
+			Action:   action,
+			StatedIn: stated_in,
+		})
+	}
+	return corrections
+}
+
+// The Creative Commons license family, such as "by" or "by-nc-nd", for each license item we know,
+// so that the different versions of a license can be told apart from different licenses
+var CC_LICENSE_FAMILIES = ccLicenseFamilies()
+
+func ccLicenseFamilies() map[string]string {
+
+	families := make(map[string]string)
+	for key, item := range CC_LICENSE_ITEM_IDS {
+		var family string
+		if strings.HasPrefix(key, "https://creativecommons.org/") {
+			// e.g., https://creativecommons.org/licenses/by-nc/4.0/ or .../publicdomain/zero/1.0/
+			parts := strings.Split(strings.TrimPrefix(key, "https://creativecommons.org/"), "/")
+			if len(parts) < 2 {
+				continue
+			}
+			family = parts[1]
+		} else if key == "CC0" {
+			family = "zero"
+		} else {
+			// e.g., "CC BY-NC" from the PMC OA list
+			family = strings.ToLower(strings.TrimPrefix(key, "CC "))
+		}
+		families[item] = family
+	}
+	return families
+}
+
+// Returns every license item in the same family as the given one, including itself
+func licenseFamilyItems(license_item string) []string {
+
+	items := []string{license_item}
+	family := CC_LICENSE_FAMILIES[license_item]
+	if family == "" {
+		return items
+	}
+	for item, item_family := range CC_LICENSE_FAMILIES {
+		if item_family == family {
+			items = appendUniqueItem(items, item)
+		}
+	}
+	sort.Strings(items[1:])
+	return items
+}
+
+// Proposes corrections to an article's license statements. EuroPMC give the license's URL, which
+// says which version it is, but the PMC OA list only gives the family, e.g., "CC BY". So when the
+// license came from PMC any version of it on wikidata is taken as agreeing, and only a license
+// from a different family is questioned.
+func licenseCorrections(item string, license_item string, license_source string, existing []ExistingStatement) []Correction {
+
+	expected := []string{license_item}
+	if license_source != EuroPMC_ITEM {
+		expected = licenseFamilyItems(license_item)
+	}
+	return findCorrections(item, LICENSE_PROPERTY, expected, existing, license_source)
+}
+
+// Returns the items for the notices that retract an article, and whether we found all of them.
+// If some aren't on wikidata, we can't tell whether a different notice on wikidata is wrong.
+func retractionItems(links []EUtils.CommentsCorrections, pmid_items map[string]string) ([]string, bool) {
+
+	items := make([]string, 0)
+	complete := true
+	for _, link := range links {
+		if link.RefType != EUtils.REF_TYPE_RETRACTION_IN {
+			continue
+		}
+		item := pmid_items[link.PMID]
+		if item == "" {
+			complete = false
+			continue
+		}
+		items = appendUniqueItem(items, item)
+	}
+	return items, complete
+}
+
+// For an article PubMed says is retracted, proposes corrections to its retracted by statements. A
+// novalue statement is always wrong, but other notices are only wrong if we know all of them.
+func retractionCorrections(item string, links []EUtils.CommentsCorrections, pmid_items map[string]string, existing []ExistingStatement) []Correction {
+
+	expected, complete := retractionItems(links, pmid_items)
+	if !complete {
+		novalues := make([]ExistingStatement, 0)
+		for _, statement := range existing {
+			if statement.NoValue {
+				novalues = append(novalues, statement)
+			}
+		}
+		existing = novalues
+	}
+	return findCorrections(item, RETRACTED_BY_PROPERTY, expected, existing, PM_ITEM)
+}
+
+func (c Correction) CurrentValue() Value {
+	if c.Current.NoValue {
+		return NoValue{}
+	}
+	return ItemValue(c.Current.Value)
+}
+
+// The removal to put in the QuickStatements file, or nil if the correction has to be made by hand
+func (c Correction) Removal() *RemoveStatement {
+	if c.Action != CORRECTION_REMOVE {
+		return nil
+	}
+	return RemovePropertyFromItem(c.Item, c.Property, c.CurrentValue())
+}
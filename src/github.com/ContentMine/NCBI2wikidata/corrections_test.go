@@ -0,0 +1,142 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ContentMine/EUtils"
+)
+
+func TestBuildStatementsSparqlQuery(t *testing.T) {
+
+	query := buildStatementsSparqlQuery([]string{"Q1", "Q2"}, LICENSE_PROPERTY)
+	if !strings.Contains(query, "VALUES ?item { wd:Q1 wd:Q2 }") {
+		t.Errorf("Items missing from query: %s", query)
+	}
+	if !strings.Contains(query, "?item p:P275 ?statement.") || !strings.Contains(query, "wdno:P275") {
+		t.Errorf("Property missing from query: %s", query)
+	}
+}
+
+func TestFindCorrections(t *testing.T) {
+
+	existing := []ExistingStatement{
+		{Value: "Q20007257"},
+		{Value: "Q6905323"},
+		{Value: "Q24082749", Referenced: true},
+		{NoValue: true},
+	}
+
+	corrections := findCorrections("Q1", LICENSE_PROPERTY, []string{"Q20007257"}, existing, EuroPMC_ITEM)
+	if len(corrections) != 3 {
+		t.Fatalf("Expected 3 corrections, got %d: %v", len(corrections), corrections)
+	}
+
+	expected := []struct {
+		value  string
+		action string
+	}{
+		{"Q6905323", CORRECTION_REMOVE},
+		{"Q24082749", CORRECTION_DEPRECATE},
+		{"novalue", CORRECTION_DEPRECATE},
+	}
+	for idx, testitem := range expected {
+		correction := corrections[idx]
+		if correction.CurrentValue().String() != testitem.value || correction.Action != testitem.action {
+			t.Errorf("Expected to %s %s, got %s %v", testitem.action, testitem.value, correction.Action, correction.CurrentValue())
+		}
+		if correction.StatedIn != EuroPMC_ITEM {
+			t.Errorf("Expected correction to be stated in %s, got %s", EuroPMC_ITEM, correction.StatedIn)
+		}
+	}
+
+	removal := corrections[0].Removal()
+	if removal == nil || removal.String() != "-Q1\tP275\tQ6905323\n" {
+		t.Errorf("Unexpected removal: %v", removal)
+	}
+	if corrections[1].Removal() != nil {
+		t.Errorf("Didn't expect a removal for a deprecation")
+	}
+}
+
+func TestLicenseFamilyItems(t *testing.T) {
+
+	items := licenseFamilyItems(CC_LICENSE_ITEM_IDS["CC BY"])
+	if items[0] != "Q6905323" {
+		t.Errorf("Expected the license itself first, got %v", items)
+	}
+	if !containsItem(items, "Q20007257") || !containsItem(items, "Q14947546") {
+		t.Errorf("Expected CC BY 4.0 and 3.0 in the CC BY family, got %v", items)
+	}
+	if containsItem(items, "Q24082749") || containsItem(items, "Q6938433") {
+		t.Errorf("Didn't expect CC BY-NC-ND 4.0 or CC0 in the CC BY family, got %v", items)
+	}
+}
+
+func TestLicenseCorrections(t *testing.T) {
+
+	existing := []ExistingStatement{{Value: "Q20007257"}}
+
+	// PMC only says CC BY, so the more specific CC BY 4.0 on wikidata agrees with it
+	corrections := licenseCorrections("Q1", "Q6905323", PMC_ITEM, existing)
+	if len(corrections) != 0 {
+		t.Errorf("Expected no corrections for a more specific version of the license, got %v", corrections)
+	}
+
+	// But a different license altogether is still wrong
+	corrections = licenseCorrections("Q1", CC_LICENSE_ITEM_IDS["CC BY-NC-ND"], PMC_ITEM, existing)
+	if len(corrections) != 1 || corrections[0].Action != CORRECTION_REMOVE || corrections[0].StatedIn != PMC_ITEM {
+		t.Errorf("Expected CC BY 4.0 to be removed for a CC BY-NC-ND paper, got %v", corrections)
+	}
+
+	// EuroPMC give the version, so a different version is wrong
+	corrections = licenseCorrections("Q1", "Q14947546", EuroPMC_ITEM, existing)
+	if len(corrections) != 1 || corrections[0].Current.Value != "Q20007257" {
+		t.Errorf("Expected CC BY 4.0 to be corrected for a CC BY 3.0 paper, got %v", corrections)
+	}
+}
+
+func TestRetractionCorrections(t *testing.T) {
+
+	links := []EUtils.CommentsCorrections{
+		{RefType: EUtils.REF_TYPE_RETRACTION_IN, PMID: "30674565"},
+		{RefType: EUtils.REF_TYPE_COMMENT_IN, PMID: "11111111"},
+	}
+	existing := []ExistingStatement{
+		{Value: "Q100"},
+		{Value: "Q200"},
+		{NoValue: true},
+	}
+
+	// With all the notices found, anything else is wrong
+	corrections := retractionCorrections("Q1", links, map[string]string{"30674565": "Q100"}, existing)
+	if len(corrections) != 2 {
+		t.Fatalf("Expected 2 corrections, got %d: %v", len(corrections), corrections)
+	}
+	if corrections[0].Current.Value != "Q200" || corrections[0].Action != CORRECTION_REMOVE {
+		t.Errorf("Expected to remove Q200, got %v", corrections[0])
+	}
+	if !corrections[1].Current.NoValue || corrections[1].Action != CORRECTION_DEPRECATE {
+		t.Errorf("Expected to deprecate novalue, got %v", corrections[1])
+	}
+
+	// Without the notice on wikidata, only novalue is known to be wrong
+	corrections = retractionCorrections("Q1", links, map[string]string{}, existing)
+	if len(corrections) != 1 || !corrections[0].Current.NoValue {
+		t.Errorf("Expected only the novalue to be corrected, got %v", corrections)
+	}
+}
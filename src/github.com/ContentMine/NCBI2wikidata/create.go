@@ -0,0 +1,101 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ContentMine/EUtils"
+)
+
+// Wikidata won't accept labels or descriptions longer than this
+const MAX_TERM_LENGTH = 250
+
+func truncateTerm(text string) string {
+
+	runes := []rune(text)
+	if len(runes) <= MAX_TERM_LENGTH {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:MAX_TERM_LENGTH-3])) + "..."
+}
+
+// Writes a date as it would be read, to the precision we know it, e.g., "28 May 2018", "May
+// 2018", or "2018"
+func formatPublicationDate(d EUtils.PublicationDate) string {
+
+	switch d.Precision {
+	case EUtils.DATE_PRECISION_DAY:
+		return fmt.Sprintf("%d %s %d", d.Day, time.Month(d.Month).String(), d.Year)
+	case EUtils.DATE_PRECISION_MONTH:
+		return fmt.Sprintf("%s %d", time.Month(d.Month).String(), d.Year)
+	default:
+		return fmt.Sprintf("%d", d.Year)
+	}
+}
+
+// Describes a paper in the way wikidata commonly does, e.g., "scholarly article by Thales De
+// Brito et al published 28 May 2018", leaving out whatever we don't know
+func describeArticle(record Record) string {
+
+	description := "scholarly article"
+
+	names := make([]string, 0, len(record.Authors))
+	for _, author := range record.Authors {
+		if name := author.GetName(); name != "" {
+			names = append(names, name)
+		}
+	}
+	switch len(names) {
+	case 0:
+	case 1:
+		description += " by " + names[0]
+	case 2:
+		description += fmt.Sprintf(" by %s and %s", names[0], names[1])
+	default:
+		description += fmt.Sprintf(" by %s et al", names[0])
+	}
+
+	if !record.PublicationDate.IsZero() {
+		description += " published " + formatPublicationDate(record.PublicationDate)
+	}
+
+	return truncateTerm(description)
+}
+
+// Starts a new item for a paper, returning the commands to create it and give it a label and
+// description. Statements for the new item should then be made on LAST_ITEM.
+func createArticleItem(record Record) []Command {
+
+	commands := []Command{&CreateStatement{}}
+
+	// A translated title isn't the paper's title in any language, so as with the title statement
+	// we leave it out rather than give the item an English label that doesn't match it
+	if record.Title != "" && !record.TitleTranslated {
+		commands = append(commands, SetLabel(LAST_ITEM, "en", truncateTerm(record.Title)))
+	}
+	return append(commands, SetDescription(LAST_ITEM, "en", describeArticle(record)))
+}
+
+func containsItem(items []string, item string) bool {
+	for _, existing := range items {
+		if existing == item {
+			return true
+		}
+	}
+	return false
+}
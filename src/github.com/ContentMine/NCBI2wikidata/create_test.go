@@ -0,0 +1,98 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ContentMine/EUtils"
+)
+
+func TestDescribeArticle(t *testing.T) {
+
+	brito := EUtils.Author{ForeName: "Thales", LastName: "De Brito"}
+	silva := EUtils.Author{ForeName: "Ana Maria", LastName: "Silva"}
+	group := EUtils.Author{CollectiveName: "Leptospirosis Study Group"}
+
+	testdata := []struct {
+		authors  []EUtils.Author
+		date     EUtils.PublicationDate
+		expected string
+	}{
+		{nil, EUtils.PublicationDate{}, "scholarly article"},
+		{[]EUtils.Author{brito}, EUtils.PublicationDate{Year: 2018, Month: 5, Day: 28, Precision: EUtils.DATE_PRECISION_DAY},
+			"scholarly article by Thales De Brito published 28 May 2018"},
+		{[]EUtils.Author{brito, silva}, EUtils.PublicationDate{Year: 2018, Month: 3, Precision: EUtils.DATE_PRECISION_MONTH},
+			"scholarly article by Thales De Brito and Ana Maria Silva published March 2018"},
+		{[]EUtils.Author{group, brito, silva}, EUtils.PublicationDate{Year: 2017, Precision: EUtils.DATE_PRECISION_YEAR},
+			"scholarly article by Leptospirosis Study Group et al published 2017"},
+	}
+
+	for _, testitem := range testdata {
+		description := describeArticle(Record{Authors: testitem.authors, PublicationDate: testitem.date})
+		if description != testitem.expected {
+			t.Errorf("Expected %q, got %q", testitem.expected, description)
+		}
+	}
+}
+
+func TestCreateArticleItem(t *testing.T) {
+
+	record := Record{
+		Title:           "Leptospirosis in \"Brazil\"",
+		Authors:         []EUtils.Author{{ForeName: "Thales", LastName: "De Brito"}},
+		PublicationDate: EUtils.PublicationDate{Year: 2018, Precision: EUtils.DATE_PRECISION_YEAR},
+	}
+
+	expected := "CREATE\n" +
+		"LAST\tLen\t\"Leptospirosis in \\\"Brazil\\\"\"\n" +
+		"LAST\tDen\t\"scholarly article by Thales De Brito published 2018\"\n"
+	commands := ""
+	for _, command := range createArticleItem(record) {
+		commands += command.String()
+	}
+	if commands != expected {
+		t.Errorf("Unexpected commands: %q", commands)
+	}
+
+	record.TitleTranslated = true
+	expected = "CREATE\n" +
+		"LAST\tDen\t\"scholarly article by Thales De Brito published 2018\"\n"
+	commands = ""
+	for _, command := range createArticleItem(record) {
+		commands += command.String()
+	}
+	if commands != expected {
+		t.Errorf("Expected no label for a translated title, got: %q", commands)
+	}
+}
+
+func TestTruncateTerm(t *testing.T) {
+
+	short := "A short title"
+	if truncateTerm(short) != short {
+		t.Errorf("Short term was changed: %q", truncateTerm(short))
+	}
+
+	long := strings.Repeat("é", MAX_TERM_LENGTH+10)
+	truncated := []rune(truncateTerm(long))
+	if len(truncated) != MAX_TERM_LENGTH {
+		t.Errorf("Expected %d characters, got %d", MAX_TERM_LENGTH, len(truncated))
+	}
+	if !strings.HasSuffix(string(truncated), "...") {
+		t.Errorf("Expected truncated term to end with an ellipsis: %q", string(truncated))
+	}
+}
@@ -0,0 +1,93 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ContentMine/EUtils"
+)
+
+// Loads a JSON object mapping agency names, as written in PubMed, to the wikidata item for the
+// funder, e.g., {"NIH HHS": "Q390551"}. Many agency names, particularly the NIH ones, don't
+// match a label on wikidata, so this lets us fill in the gaps. An empty filename gives no overrides.
+func LoadFunderOverrides(filename string) (map[string]string, error) {
+
+	overrides := make(map[string]string)
+	if filename == "" {
+		return overrides, nil
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	err = json.NewDecoder(f).Decode(&overrides)
+	if err != nil {
+		return nil, err
+	}
+	for agency, item := range overrides {
+		if !strings.HasPrefix(item, "Q") {
+			return nil, fmt.Errorf("Funder %s has an invalid item %s", agency, item)
+		}
+	}
+	return overrides, nil
+}
+
+// Works out the wikidata items for the given agencies, using the overrides where we have them
+// and looking the rest up by name. The lookup is passed in so it can be swapped out in tests.
+func resolveFunders(agencies []string, overrides map[string]string, lookup func([]string) (map[string]string, error)) (map[string]string, error) {
+
+	funders := make(map[string]string)
+	remaining := make([]string, 0)
+	for _, agency := range agencies {
+		if item, ok := overrides[agency]; ok {
+			funders[agency] = item
+		} else {
+			remaining = append(remaining, agency)
+		}
+	}
+
+	found, err := lookup(remaining)
+	if err != nil {
+		return nil, err
+	}
+	for agency, item := range found {
+		funders[agency] = item
+	}
+	return funders, nil
+}
+
+// Lists the grants for the CSV, with the funder's item where we found one, e.g.,
+// "Wellcome Trust (Q1234) 098051; NIGMS NIH HHS R01 GM123456"
+func formatGrants(grants []EUtils.Grant, funder_items map[string]string) string {
+
+	parts := make([]string, len(grants))
+	for idx, grant := range grants {
+		parts[idx] = grant.Agency
+		if funder_items[grant.Agency] != "" {
+			parts[idx] += fmt.Sprintf(" (%s)", funder_items[grant.Agency])
+		}
+		if grant.GrantID != "" {
+			parts[idx] += " " + grant.GrantID
+		}
+	}
+	return strings.Join(parts, "; ")
+}
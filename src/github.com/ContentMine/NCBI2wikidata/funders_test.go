@@ -0,0 +1,79 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/ContentMine/EUtils"
+)
+
+func TestLoadFunderOverrides(t *testing.T) {
+
+	overrides, err := LoadFunderOverrides("testdata/funders.json")
+	if err != nil {
+		t.Fatalf("Failed to load overrides: %v", err)
+	}
+	if len(overrides) != 2 || overrides["NIH HHS"] != "Q390551" {
+		t.Errorf("Unexpected overrides: %v", overrides)
+	}
+
+	_, err = LoadFunderOverrides("testdata/funders_invalid.json")
+	if err == nil {
+		t.Errorf("Expected error loading overrides with an invalid item")
+	}
+
+	overrides, err = LoadFunderOverrides("")
+	if err != nil || len(overrides) != 0 {
+		t.Errorf("Expected no overrides without a file: %v %v", overrides, err)
+	}
+}
+
+func TestResolveFunders(t *testing.T) {
+
+	overrides := map[string]string{"NIGMS NIH HHS": "Q100"}
+
+	var looked_up []string
+	lookup := func(agencies []string) (map[string]string, error) {
+		looked_up = agencies
+		return map[string]string{"Wellcome Trust": "Q200"}, nil
+	}
+
+	funders, err := resolveFunders([]string{"NIGMS NIH HHS", "Wellcome Trust", "Unknown Agency"}, overrides, lookup)
+	if err != nil {
+		t.Fatalf("Failed to resolve funders: %v", err)
+	}
+
+	if len(looked_up) != 2 || looked_up[0] != "Wellcome Trust" || looked_up[1] != "Unknown Agency" {
+		t.Errorf("Expected only funders without overrides to be looked up, got %v", looked_up)
+	}
+	if funders["NIGMS NIH HHS"] != "Q100" || funders["Wellcome Trust"] != "Q200" || funders["Unknown Agency"] != "" {
+		t.Errorf("Unexpected funders: %v", funders)
+	}
+}
+
+func TestFormatGrants(t *testing.T) {
+
+	grants := []EUtils.Grant{
+		{GrantID: "R01 GM123456", Agency: "NIGMS NIH HHS"},
+		{Agency: "Wellcome Trust"},
+	}
+
+	formatted := formatGrants(grants, map[string]string{"Wellcome Trust": "Q200"})
+	expected := "NIGMS NIH HHS R01 GM123456; Wellcome Trust (Q200)"
+	if formatted != expected {
+		t.Errorf("Expected %s, got %s", expected, formatted)
+	}
+}
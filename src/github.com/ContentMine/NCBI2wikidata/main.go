@@ -16,6 +16,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -23,6 +24,7 @@ import (
 	"log"
 	"net/url"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
 	"time"
@@ -37,7 +39,24 @@ const EFETCH_BATCH_SIZE = 200
 const NCBI_LICENSE_URL = "ftp://ftp.ncbi.nlm.nih.gov:21/pub/pmc/oa_file_list.txt"
 const NCBI_FILE_FILE = "oa_file_list.txt"
 
+// How long we'll wait on any one NCBI request by default. This covers reading the whole reply, so
+// needs to allow for a full batch of articles to be fetched.
+const NCBI_REQUEST_TIMEOUT = 5 * time.Minute
+
+// How long we'll wait to connect to the NCBI FTP server
+const FTP_DIAL_TIMEOUT = 30 * time.Second
+
+// The search we run for each term in the feed, restricted to the publication types we want
+const SEARCH_QUERY_TEMPLATE = "\"%s\"[Mesh Major Topic] AND (%s)"
+const PUBLICATION_TYPE_QUERY_TEMPLATE = "\"%s\"[ptyp]"
+
 func FetchLicenses(target_filename string, ftp_location string) error {
+	return FetchLicensesWithContext(context.Background(), target_filename, ftp_location)
+}
+
+// Like FetchLicenses, but gives up once the context is cancelled. The file is downloaded to a
+// temporary name first, so an interrupted fetch doesn't leave a partial list that looks complete.
+func FetchLicensesWithContext(ctx context.Context, target_filename string, ftp_location string) error {
 	url, err := url.Parse(ftp_location)
 	if err != nil {
 		return err
@@ -47,10 +66,11 @@ func FetchLicenses(target_filename string, ftp_location string) error {
 		return fmt.Errorf("We require an FTP URL, not %s", ftp_location)
 	}
 
-	client, err := ftp.Dial(url.Host)
+	client, err := ftp.Dial(url.Host, ftp.DialWithContext(ctx), ftp.DialWithTimeout(FTP_DIAL_TIMEOUT))
 	if err != nil {
 		return err
 	}
+	defer client.Quit()
 
 	err = client.Login("anonymous", "anonymous")
 	if err != nil {
@@ -63,14 +83,38 @@ func FetchLicenses(target_filename string, ftp_location string) error {
 	}
 	defer resp.Close()
 
-	f, err := os.Create(target_filename)
+	// The FTP connection doesn't watch the context once it is established, so break off the
+	// transfer ourselves if we're cancelled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			resp.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	partial_filename := target_filename + ".part"
+	f, err := os.Create(partial_filename)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
 	_, err = io.Copy(f, resp)
-	return err
+	close_err := f.Close()
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err == nil {
+		err = close_err
+	}
+	if err != nil {
+		os.Remove(partial_filename)
+		return err
+	}
+
+	return os.Rename(partial_filename, target_filename)
 }
 
 func set_to_list(m map[string]string) []string {
@@ -88,14 +132,14 @@ func set_to_list(m map[string]string) []string {
 // First line is date file was generated, rest are tab separated info on papers. Example:
 // oa_package/87/30/PMC17774.tar.gz	Arthritis Res. 1999 Oct 14; 1(1):63-70	PMC17774	PMID:11056661	NO-CC CODE
 //
-func LoadLicenses(filename string, license_map map[string]string) error {
+func LoadLicenses(ctx context.Context, filename string, license_map map[string]string) error {
 
 	f, err := os.Open(filename)
 	if err != nil {
 		if os.IsNotExist(err) {
 			// File just not there, so try to fetch it first
 			log.Printf("Fetching PMC open access list, this may take some time...")
-			err := FetchLicenses(filename, NCBI_LICENSE_URL)
+			err := FetchLicensesWithContext(ctx, filename, NCBI_LICENSE_URL)
 			if err != nil {
 				return err
 			}
@@ -151,18 +195,31 @@ func LoadLicenses(filename string, license_map map[string]string) error {
 
 type Record struct {
 	Title           string
+	TitleTranslated bool
+	Volume          string
+	Issue           string
+	Pages           string
 	MainSubjects    []EUtils.MeshDescriptorName
 	IsReview        bool
-	PublicationDate string
+	PublicationDate EUtils.PublicationDate
 	Publication     string
 	ISSN            string
 	PMCLicense      string
 	EPMCLicenseLink string
 	PMID            string
 	PMCID           string
+	DOI             string
 	IsRetracted     bool
 	IsRetraction    bool
 	RetractedByPMID string
+	Relations       []EUtils.CommentsCorrections
+	PubTypes        []EUtils.PublicationType
+	Authors         []EUtils.Author
+	Grants          []EUtils.Grant
+	References      []EUtils.Reference
+	Abstract        string
+	// Not used for statements, just shown in the CSV for a human to consider
+	SuggestedTopics []EUtils.MeshDescriptorName
 }
 
 func GetEuroPMCLicenseLinkForPMCID(pmcid string) (string, error) {
@@ -195,106 +252,264 @@ func GetEuroPMCLicenseLinkForPMCID(pmcid string) (string, error) {
 	return license_info.Link, nil
 }
 
-func ArticleToRecord(article EUtils.PubmedArticle) Record {
+// The date rule picks which of the article's dates is used as its publication date
+func ArticleToRecord(article EUtils.PubmedArticle, date_rule EUtils.DateRule, substance_rule SubstanceRule) Record {
+
+	title := article.GetTitle()
+	abstract := article.GetAbstract().GetText()
+	substance_subjects, substance_suggestions := splitSubstanceTopics(article.GetSubstanceTopics(), substance_rule)
 
 	return Record{
-		Title:           article.MedlineCitation.Article[0].ArticleTitle,
+		Title:           title,
+		TitleTranslated: article.IsTitleTranslated(),
+		Volume:          article.GetVolume(),
+		Issue:           article.GetIssue(),
+		Pages:           article.GetPages(),
 		PMID:            article.MedlineCitation.PMID,
 		PMCID:           article.GetPMCID(),
+		DOI:             article.GetDOI(),
 		PMCLicense:      "",
-		MainSubjects:    article.GetMajorTopics(),
-		PublicationDate: article.GetPublicationDateString(),
+		MainSubjects:    append(article.GetMajorTopics(), substance_subjects...),
+		PublicationDate: article.GetPublicationDate(date_rule),
 		Publication:     article.MedlineCitation.Article[0].Journal.Title,
 		ISSN:            article.MedlineCitation.Article[0].Journal.ISSN,
 		IsReview:        article.IsReview(),
 		IsRetracted:     article.IsRetracted(),
 		IsRetraction:    article.IsRetraction(),
 		RetractedByPMID: article.GetRetractedInPMID(),
+		Relations:       article.GetCommentsCorrections(),
+		PubTypes:        article.GetPublicationTypes(),
+		Authors:         article.GetAuthors(),
+		Grants:          article.GetGrants(),
+		References:      article.GetReferences(),
+		Abstract:        abstract,
+		SuggestedTopics: append(suggestMainSubjects(title, abstract, article.GetMinorTopics()), substance_suggestions...),
+	}
+}
+
+// Lists MeSH subjects for the CSV, along with any wikidata items we found for them, e.g.,
+// "Leptospirosis (Q273507); Kidney Diseases"
+func formatSubjects(subjects []EUtils.MeshDescriptorName, subject_items SubjectItems) string {
+
+	formatted := ""
+	for idx, subject := range subjects {
+		if idx != 0 {
+			formatted += "; "
+		}
+		formatted += subject.Name
+		l := strings.Join(subject_items.Items(subject.MeshID), ", ")
+		if l != "" {
+			formatted += fmt.Sprintf(" (%s)", l)
+		}
+	}
+	return formatted
+}
+
+// Finds the wikidata item for a cited work, by its PMID or failing that its DOI
+func referenceToWDItem(ref EUtils.Reference, pmid_items map[string]string, doi_items map[string]string) string {
+
+	item := pmid_items[ref.GetPMID()]
+	if item == "" {
+		item = doi_items[ref.GetDOI()]
 	}
+	return item
 }
 
-func batch(term string, ncbi_api_key string, csv_file *os.File, qs_file *os.File) error {
+// Finds the wikidata item for a paper. Not every paper on wikidata has its PMCID recorded, so if
+// we can't find it that way we try its DOI and then its PMID.
+func recordToWDItem(record Record, pmcid_items map[string]string, doi_items map[string]string, pmid_items map[string]string) string {
+
+	item := pmcid_items[record.PMCID]
+	if item == "" && record.DOI != "" {
+		item = doi_items[record.DOI]
+	}
+	if item == "" {
+		item = pmid_items[record.PMID]
+	}
+	return item
+}
+
+// The settings for a run that stay the same for every term in the feed
+type BatchConfig struct {
+	Client           *EUtils.Client
+	DateRule         EUtils.DateRule
+	SubstanceRule    SubstanceRule
+	PublicationTypes PublicationTypeTable
+	SearchTypes      []PublicationTypeMapping
+	FunderOverrides  map[string]string
+
+	// Whether to create items for papers that aren't on wikidata yet, and the PMIDs of those
+	// we've created so far, so a paper found by more than one term is only created once
+	CreateMissing bool
+	Created       map[string]bool
+
+	// Whether to check the statements already on wikidata against what PubMed says
+	Corrections bool
+}
+
+func batch(ctx context.Context, term string, config BatchConfig, csv_file *os.File, qs_writer QuickStatementsWriter, refs_file *os.File, corrections_file *os.File) error {
 
 	// Because we use the history feature of the eUtilities API, it doesn't matter how many
 	// things get returned here, we rely on the eFetch API to get all the deets. Hence the
-	// single request here. We really are just doing this to light up things later
+	// single record asked for in each request. NCBI won't let us page past the first 10,000
+	// records though, so for broad terms the search gets split up into date windows that
+	// are each small enough to fetch in full.
 	search_request := EUtils.ESearchRequest{
 		DB:         "pubmed",
-		APIKey:     os.Getenv("NCBI_API_KEY"),
 		Term:       term,
 		RetMax:     1,
 		UseHistory: true,
 	}
 
-	search_resp, err := search_request.Do()
-	if err != nil {
-		return err
-	}
-
-	count, err := strconv.Atoi(search_resp.Count)
-	if err != nil {
-		return err
-	}
-	log.Printf("Search returned %d matches for %s.\n", count, term)
-
 	// Things to build up as we fetch the results from PMC...
 	all_records := make([]Record, 0)
 	pmid_set := make(map[string]string, 0)
 	pmcid_set := make(map[string]string, 0)
+	doi_set := make(map[string]string, 0)
 	issn_set := make(map[string]string, 0)
 	main_subject_set := make(map[string]string, 0)
+	orcid_set := make(map[string]string, 0)
+	funder_set := make(map[string]string, 0)
+	concept_set := make(map[string]string, 0)
 	license_map := make(map[string]string, 0)
 
-	for i := 0; i < count; i += EFETCH_BATCH_SIZE {
+	// An article can turn up in more than one window, so track what we've already seen
+	seen_pmids := make(map[string]bool, 0)
+	skipped := 0
 
-		fetch_request := EUtils.EFetchHistoryRequest{
-			DB:       "pubmed",
-			WebEnv:   search_resp.WebEnv,
-			QueryKey: search_resp.QueryKey,
-			APIKey:   ncbi_api_key,
-			RetStart: i,
-			RetMax:   EFETCH_BATCH_SIZE,
+	windows := search_request.WindowsWithContext(ctx, config.Client)
+	first_window := true
+	for {
+		search_resp, err := windows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
 		}
-		// this is a lazy way to do rate limiting - we're allowed ten requests on the NCBI API a second. This
-		// ensures we'll never hit this. We could do better, but it's not worth the complexity IMHO.
-		time.Sleep(100 * time.Millisecond)
 
-		fetch_resp, err := fetch_request.Do()
+		count, err := strconv.Atoi(search_resp.Count)
 		if err != nil {
 			return err
 		}
+		log.Printf("Search returned %d matches for %s.\n", count, term)
 
-		log.Printf("Fetched %d articles for %s.\n", len(fetch_resp.Articles), term)
+		if first_window {
+			first_window = false
+			if !foundAsMeshTerm(search_resp) {
+				log.Printf("Warning: no MeSH major topic was found for %s, check the term in the feed matches MeSH.", term)
+			}
+		}
 
-		for _, article := range fetch_resp.Articles {
+		if count > EUtils.ESEARCH_MAX_RECORDS {
+			log.Printf("Warning: only the first %d of %d matches for %s can be fetched.", EUtils.ESEARCH_MAX_RECORDS, count, term)
+			count = EUtils.ESEARCH_MAX_RECORDS
+		}
 
-			// Distill out what we want from the article
-			record := ArticleToRecord(article)
+		for i := 0; i < count; i += EFETCH_BATCH_SIZE {
 
-			if record.PMID != "" {
-				license_map[record.PMID] = ""
+			fetch_request := EUtils.EFetchHistoryRequest{
+				DB:       "pubmed",
+				WebEnv:   search_resp.WebEnv,
+				QueryKey: search_resp.QueryKey,
+				RetStart: i,
+				RetMax:   EFETCH_BATCH_SIZE,
 			}
 
-			// make a note of the things we need to look up on wikidata
-			if record.RetractedByPMID != "" {
-				pmid_set[record.RetractedByPMID] = ""
-			}
-			for _, subject := range record.MainSubjects {
-				main_subject_set[subject.MeshID] = ""
-			}
-			if record.ISSN != "" {
-				issn_set[record.ISSN] = ""
+			// We stream the articles rather than load the whole reply, as for broad terms the XML
+			// is large and we only need to keep a small record from each article
+			articles, err := fetch_request.StreamWithContext(ctx, config.Client)
+			if err != nil {
+				return err
 			}
-			if record.PMCID != "" {
-				pmcid_set[record.PMCID] = ""
-				license_map[record.PMCID] = ""
+
+			fetched := 0
+			for {
+				article, err := articles.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					articles.Close()
+					return err
+				}
+				fetched += 1
+
+				// Distill out what we want from the article
+				record := ArticleToRecord(article, config.DateRule, config.SubstanceRule)
+				if !matchesPublicationTypes(record.PubTypes, config.SearchTypes) {
+					skipped += 1
+					continue
+				}
+
+				if record.PMID != "" {
+					if seen_pmids[record.PMID] {
+						continue
+					}
+					seen_pmids[record.PMID] = true
+					license_map[record.PMID] = ""
+				}
+
+				// make a note of the things we need to look up on wikidata
+				if record.RetractedByPMID != "" {
+					pmid_set[record.RetractedByPMID] = ""
+				}
+				for _, link := range record.Relations {
+					if link.PMID != "" && isMappedRelation(link) {
+						pmid_set[link.PMID] = ""
+					}
+				}
+				for _, subject := range append(record.MainSubjects, record.SuggestedTopics...) {
+					main_subject_set[subject.MeshID] = ""
+					if EUtils.IsSupplementaryConceptID(subject.MeshID) {
+						concept_set[subject.MeshID] = ""
+					}
+				}
+				if record.ISSN != "" {
+					issn_set[record.ISSN] = ""
+				}
+				for _, ref := range record.References {
+					if ref.GetPMID() != "" {
+						pmid_set[ref.GetPMID()] = ""
+					}
+					if ref.GetDOI() != "" {
+						doi_set[ref.GetDOI()] = ""
+					}
+				}
+				for _, grant := range record.Grants {
+					funder_set[grant.Agency] = ""
+				}
+				for _, author := range record.Authors {
+					orcid := author.GetORCID()
+					if orcid != "" {
+						orcid_set[orcid] = ""
+					}
+				}
+				if record.PMCID != "" {
+					pmcid_set[record.PMCID] = ""
+					license_map[record.PMCID] = ""
+				}
+				if record.DOI != "" {
+					doi_set[record.DOI] = ""
+				}
+				pmid_set[record.PMID] = ""
+
+				all_records = append(all_records, record)
 			}
+			articles.Close()
 
-			all_records = append(all_records, record)
+			log.Printf("Fetched %d articles for %s.\n", fetched, term)
 		}
 	}
 
-	err = LoadLicenses(NCBI_FILE_FILE, license_map)
+	if skipped > 0 {
+		log.Printf("Skipped %d articles for %s that weren't of the publication types searched for.\n", skipped, term)
+	}
+
+	err := LoadLicenses(ctx, NCBI_FILE_FILE, license_map)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 
 	licensed_records := make([]Record, 0)
 
@@ -317,38 +532,122 @@ func batch(term string, ncbi_api_key string, csv_file *os.File, qs_file *os.File
 
 	pmcid_list := set_to_list(pmcid_set)
 	log.Printf("Getting IDs for %d PMCID items", len(pmcid_list))
-	pmcid_wikidata_items, err := PMCIDsToWDItem(pmcid_list)
+	pmcid_wikidata_items, err := PMCIDsToWDItem(ctx, pmcid_list)
 	if err != nil {
 		return fmt.Errorf("Failed fetching %d PMCID items: %v", len(pmcid_list), err)
 	}
+	doi_list := set_to_list(doi_set)
+	log.Printf("Getting IDs for %d DOI items", len(doi_list))
+	doi_wikidata_items, err := DOIsToWDItem(ctx, doi_list)
+	if err != nil {
+		return fmt.Errorf("Failed fetching %d DOI items: %v", len(doi_list), err)
+	}
 	pmid_list := set_to_list(pmid_set)
 	log.Printf("Getting IDs for %d PMID items", len(pmid_list))
-	pmid_wikidata_items, err := PMIDsToWDItem(pmid_list)
+	pmid_wikidata_items, err := PMIDsToWDItem(ctx, pmid_list)
 	if err != nil {
 		return fmt.Errorf("Failed fetching %d PMID items: %v", len(pmid_list), err)
 	}
 	issn_list := set_to_list(issn_set)
 	log.Printf("Getting IDs for %d ISSN items", len(issn_list))
-	issn_wikidata_items, err := ISSNsToWDItem(issn_list)
+	issn_wikidata_items, err := ISSNsToWDItem(ctx, issn_list)
 	if err != nil {
 		return fmt.Errorf("Failed fetching %d ISSN items: %v", len(issn_list), err)
 	}
+	orcid_list := set_to_list(orcid_set)
+	log.Printf("Getting IDs for %d ORCID items", len(orcid_list))
+	orcid_wikidata_items, err := ORCIDsToWDItem(ctx, orcid_list)
+	if err != nil {
+		return fmt.Errorf("Failed fetching %d ORCID items: %v", len(orcid_list), err)
+	}
+	funder_list := set_to_list(funder_set)
+	log.Printf("Getting IDs for %d funder items", len(funder_list))
+	funder_wikidata_items, err := resolveFunders(funder_list, config.FunderOverrides, func(agencies []string) (map[string]string, error) {
+		return FundersToWDItem(ctx, agencies)
+	})
+	if err != nil {
+		return fmt.Errorf("Failed fetching %d funder items: %v", len(funder_list), err)
+	}
+	concept_list := set_to_list(concept_set)
+	log.Printf("Getting IDs for %d supplementary concept items", len(concept_list))
+	concept_wikidata_items, err := SupplementaryConceptsToWDItem(ctx, concept_list)
+	if err != nil {
+		return fmt.Errorf("Failed fetching %d supplementary concept items: %v", len(concept_list), err)
+	}
+
+	// For the concepts that aren't on wikidata, we'll need the items for the headings they map to
+	missing_concepts := make([]string, 0)
+	for _, concept := range concept_list {
+		if concept_wikidata_items[concept] == "" {
+			missing_concepts = append(missing_concepts, concept)
+		}
+	}
+	concept_mappings := make(map[string][]string, 0)
+	if len(missing_concepts) > 0 {
+		log.Printf("Getting MeSH mappings for %d supplementary concepts", len(missing_concepts))
+		concept_mappings, err = GetMeshMappings(ctx, missing_concepts)
+		if err != nil {
+			return fmt.Errorf("Failed fetching MeSH mappings for %d supplementary concepts: %v", len(missing_concepts), err)
+		}
+		for _, descriptors := range concept_mappings {
+			for _, descriptor := range descriptors {
+				main_subject_set[descriptor] = ""
+			}
+		}
+	}
+
 	main_subject_list := set_to_list(main_subject_set)
 	log.Printf("Getting IDs for %d drug/disease items", len(main_subject_list))
-	drug_wikidata_items, err := DrugsToWDItem(main_subject_list)
+	drug_wikidata_items, err := DrugsToWDItem(ctx, main_subject_list)
 	if err != nil {
 		return fmt.Errorf("Failed fetching drug %d items: %v", len(main_subject_list), err)
 	}
-	disease_wikidata_items, err := DiseasesToWDItem(main_subject_list)
+	disease_wikidata_items, err := DiseasesToWDItem(ctx, main_subject_list)
 	if err != nil {
 		return fmt.Errorf("Failed fetching %d disease items: %v", len(main_subject_list), err)
 	}
+	subject_items := SubjectItems{
+		Drugs:    drug_wikidata_items,
+		Diseases: disease_wikidata_items,
+		Concepts: concept_wikidata_items,
+		Mappings: concept_mappings,
+	}
+
+	// In corrections mode we need to know what's on wikidata already for the papers we found
+	existing_statements := make(ExistingStatements)
+	if config.Corrections {
+		item_set := make(map[string]string, 0)
+		for _, record := range licensed_records {
+			item := recordToWDItem(record, pmcid_wikidata_items, doi_wikidata_items, pmid_wikidata_items)
+			if item != "" {
+				item_set[item] = ""
+			}
+		}
+		item_list := set_to_list(item_set)
+		log.Printf("Getting existing statements for %d items", len(item_list))
+		existing_statements, err = GetStatementsFromWikiData(ctx, item_list, []string{LICENSE_PROPERTY, RETRACTED_BY_PROPERTY})
+		if err != nil {
+			return fmt.Errorf("Failed fetching statements for %d items: %v", len(item_list), err)
+		}
+	}
 
-	now := time.Now()
+	retrieved := DateValue(time.Now())
+	unresolved_references := 0
+	proposed_corrections := 0
+	created := 0
+	missing := 0
+
+	// Both sides of a relation can be in the same batch, and each will make the same statements
+	emitted_relations := make(map[relationStatement]bool, 0)
 
 	for _, record := range licensed_records {
 
-		item := pmcid_wikidata_items[record.PMCID]
+		// Stop between records, so what we've written so far is complete
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		item := recordToWDItem(record, pmcid_wikidata_items, doi_wikidata_items, pmid_wikidata_items)
 		issn_item := issn_wikidata_items[record.ISSN]
 
 		// see of we can get better license detail from EuroPMC
@@ -368,97 +667,223 @@ func batch(term string, ncbi_api_key string, csv_file *os.File, qs_file *os.File
 
 		retracted_by_item := pmid_wikidata_items[record.RetractedByPMID]
 
-		if item != "" {
-			statement := AddStringPropertyToItem(item, PMCID_PROPERTY, record.PMCID)
-			statement.AddSource(STATED_IN_SOURCE, PMC_ITEM)
-			statement.AddSource(RETRIEVED_AT_DATE_SOURCE, fmt.Sprintf("+%04d-%02d-%02dT00:00:00Z/11", now.Year(), now.Month(), now.Day()))
-			qs_file.WriteString(fmt.Sprintf("%v", statement))
+		// Papers that aren't on wikidata at all get a new item if we've been asked to make them,
+		// in which case the statements that follow are made on that new item. A paper found by
+		// an earlier term will already have been created, so is left alone.
+		target := item
+		if item == "" && !config.Created[record.PMID] {
+			if config.CreateMissing && record.Title != "" {
+				config.Created[record.PMID] = true
+				created += 1
+				for _, command := range createArticleItem(record) {
+					qs_writer.Write(command)
+				}
+				target = LAST_ITEM
+			} else {
+				missing += 1
+			}
+		}
+
+		if target != "" {
+			var statement *AddStatement
 
-			if record.PublicationDate != "" {
-				statement = AddStringPropertyToItem(item, PUBLICATION_DATE_PROPERTY, record.PublicationDate)
-				statement.AddSource(STATED_IN_SOURCE, PMC_ITEM)
-				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, fmt.Sprintf("+%04d-%02d-%02dT00:00:00Z/11", now.Year(), now.Month(), now.Day()))
-				qs_file.WriteString(fmt.Sprintf("%v", statement))
+			if record.PMCID != "" {
+				statement = AddPropertyToItem(target, PMCID_PROPERTY, ExternalIDValue(record.PMCID))
+				statement.AddSource(STATED_IN_SOURCE, ItemValue(PMC_ITEM))
+				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, retrieved)
+				qs_writer.Write(statement)
 			}
 
-			if record.IsReview {
-				statement := AddItemPropertyToItem(item, INSTANCE_OF_PROPERTY, REVIEW_ARTICLE_ITEM)
-				statement.AddSource(STATED_IN_SOURCE, PM_ITEM)
-				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, fmt.Sprintf("+%04d-%02d-%02dT00:00:00Z/11", now.Year(), now.Month(), now.Day()))
-				qs_file.WriteString(fmt.Sprintf("%v", statement))
+			if record.PMID != "" {
+				statement = AddPropertyToItem(target, PMID_PROPERTY, ExternalIDValue(record.PMID))
+				statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, retrieved)
+				qs_writer.Write(statement)
+			}
+
+			if record.DOI != "" {
+				statement = AddPropertyToItem(target, DOI_PROPERTY, ExternalIDValue(record.DOI))
+				statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, retrieved)
+				qs_writer.Write(statement)
+			}
+
+			if !record.PublicationDate.IsZero() {
+				statement = AddPropertyToItem(target, PUBLICATION_DATE_PROPERTY, PublicationDateValue(record.PublicationDate))
+				statement.AddSource(STATED_IN_SOURCE, ItemValue(PMC_ITEM))
+				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, retrieved)
+				qs_writer.Write(statement)
+			}
+
+			// A translated title isn't the title of the work, so only English titles are used
+			if record.Title != "" && !record.TitleTranslated {
+				statement = AddPropertyToItem(target, TITLE_PROPERTY, MonolingualTextValue{Language: "en", Text: record.Title})
+				statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, retrieved)
+				qs_writer.Write(statement)
+			}
+
+			if record.Volume != "" {
+				statement = AddStringPropertyToItem(target, VOLUME_PROPERTY, record.Volume)
+				statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, retrieved)
+				qs_writer.Write(statement)
+			}
+
+			if record.Issue != "" {
+				statement = AddStringPropertyToItem(target, ISSUE_PROPERTY, record.Issue)
+				statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, retrieved)
+				qs_writer.Write(statement)
+			}
+
+			if record.Pages != "" {
+				statement = AddStringPropertyToItem(target, PAGES_PROPERTY, record.Pages)
+				statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, retrieved)
+				qs_writer.Write(statement)
+			}
+
+			// A new item needs to be a scholarly article to be found again by the PMCID, PMID, and
+			// DOI lookups, even if NLM haven't given it the Journal Article type
+			type_items := config.PublicationTypes.Items(record.PubTypes)
+			if target == LAST_ITEM && !containsItem(type_items, SCHOLARLY_ARTICLE_TYPE) {
+				type_items = append([]string{SCHOLARLY_ARTICLE_TYPE}, type_items...)
+			}
+			for _, type_item := range type_items {
+				statement = AddItemPropertyToItem(target, INSTANCE_OF_PROPERTY, type_item)
+				statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, retrieved)
+				qs_writer.Write(statement)
 			}
 
 			if license_item != "" {
-				statement := AddItemPropertyToItem(item, LICENSE_PROPERTY, license_item)
-				statement.AddSource(STATED_IN_SOURCE, license_source)
-				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, fmt.Sprintf("+%04d-%02d-%02dT00:00:00Z/11", now.Year(), now.Month(), now.Day()))
-				qs_file.WriteString(fmt.Sprintf("%v", statement))
+				statement := AddItemPropertyToItem(target, LICENSE_PROPERTY, license_item)
+				statement.AddSource(STATED_IN_SOURCE, ItemValue(license_source))
+				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, retrieved)
+				qs_writer.Write(statement)
 			}
 
 			if issn_item != "" {
-				statement := AddItemPropertyToItem(item, PUBLICATION_PROPERTY, issn_item)
-				statement.AddSource(STATED_IN_SOURCE, PMC_ITEM)
-				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, fmt.Sprintf("+%04d-%02d-%02dT00:00:00Z/11", now.Year(), now.Month(), now.Day()))
-				qs_file.WriteString(fmt.Sprintf("%v", statement))
-			}
-
-			for _, subject := range record.MainSubjects {
-				if drug_wikidata_items[subject.MeshID] != "" {
-					statement = AddItemPropertyToItem(item, MAIN_SUBJECT_PROPERTY, drug_wikidata_items[subject.MeshID])
-					statement.AddSource(STATED_IN_SOURCE, PM_ITEM)
-					statement.AddSource(RETRIEVED_AT_DATE_SOURCE, fmt.Sprintf("+%04d-%02d-%02dT00:00:00Z/11", now.Year(), now.Month(), now.Day()))
-					qs_file.WriteString(fmt.Sprintf("%v", statement))
-				}
-				if disease_wikidata_items[subject.MeshID] != "" {
-					statement = AddItemPropertyToItem(item, MAIN_SUBJECT_PROPERTY, disease_wikidata_items[subject.MeshID])
-					statement.AddSource(STATED_IN_SOURCE, PM_ITEM)
-					statement.AddSource(RETRIEVED_AT_DATE_SOURCE, fmt.Sprintf("+%04d-%02d-%02dT00:00:00Z/11", now.Year(), now.Month(), now.Day()))
-					qs_file.WriteString(fmt.Sprintf("%v", statement))
-				}
+				statement := AddItemPropertyToItem(target, PUBLICATION_PROPERTY, issn_item)
+				statement.AddSource(STATED_IN_SOURCE, ItemValue(PMC_ITEM))
+				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, retrieved)
+				qs_writer.Write(statement)
 			}
 
-			if record.IsRetracted {
-				statement := AddItemPropertyToItem(item, INSTANCE_OF_PROPERTY, RETRACTED_PAPER_TYPE)
-				statement.AddSource(STATED_IN_SOURCE, PM_ITEM)
-				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, fmt.Sprintf("+%04d-%02d-%02dT00:00:00Z/11", now.Year(), now.Month(), now.Day()))
-				qs_file.WriteString(fmt.Sprintf("%v", statement))
+			// Authors we can find on wikidata by their ORCID get linked to directly, the rest just
+			// get their name recorded. Either way we note where they came in the author list.
+			for idx, author := range record.Authors {
+				name := author.GetName()
+				author_item := orcid_wikidata_items[author.GetORCID()]
+				if author_item != "" {
+					statement = AddItemPropertyToItem(target, AUTHOR_PROPERTY, author_item)
+					statement.AddQualifier(OBJECT_NAMED_AS_QUALIFIER, StringValue(name))
+				} else if name != "" {
+					statement = AddStringPropertyToItem(target, AUTHOR_NAME_STRING_PROPERTY, name)
+				} else {
+					continue
+				}
+				statement.AddQualifier(SERIES_ORDINAL_QUALIFIER, StringValue(strconv.Itoa(idx+1)))
+				statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, retrieved)
+				qs_writer.Write(statement)
+			}
 
-				if retracted_by_item != "" {
-					statement := AddItemPropertyToItem(item, RETRACTED_BY_PROPERTY, retracted_by_item)
-					statement.AddSource(STATED_IN_SOURCE, PM_ITEM)
-					statement.AddSource(RETRIEVED_AT_DATE_SOURCE, fmt.Sprintf("+%04d-%02d-%02dT00:00:00Z/11", now.Year(), now.Month(), now.Day()))
-					qs_file.WriteString(fmt.Sprintf("%v", statement))
+			// A funder can give more than one grant, so each grant gets its own statement
+			for _, grant := range record.Grants {
+				funder_item := funder_wikidata_items[grant.Agency]
+				if funder_item == "" {
+					continue
 				}
+				statement = AddItemPropertyToItem(target, SPONSOR_PROPERTY, funder_item)
+				if grant.GrantID != "" {
+					statement.AddQualifier(GRANT_NUMBER_QUALIFIER, ExternalIDValue(grant.GrantID))
+				}
+				statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, retrieved)
+				qs_writer.Write(statement)
 			}
 
-			if record.IsRetraction {
-				statement := AddItemPropertyToItem(item, INSTANCE_OF_PROPERTY, RETRACTION_NOTICE_TYPE)
-				statement.AddSource(STATED_IN_SOURCE, PM_ITEM)
-				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, fmt.Sprintf("+%04d-%02d-%02dT00:00:00Z/11", now.Year(), now.Month(), now.Day()))
-				qs_file.WriteString(fmt.Sprintf("%v", statement))
+			// A work can be listed more than once in a reference list, but we only want to cite it once
+			cited_items := make(map[string]bool, 0)
+			for _, ref := range record.References {
+				cited_item := referenceToWDItem(ref, pmid_wikidata_items, doi_wikidata_items)
+				if cited_item == "" || cited_items[cited_item] {
+					continue
+				}
+				cited_items[cited_item] = true
+				statement = AddItemPropertyToItem(target, CITES_WORK_PROPERTY, cited_item)
+				statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, retrieved)
+				qs_writer.Write(statement)
 			}
 
-			qs_file.WriteString("\n")
-		}
+			for _, statement := range mainSubjectStatements(target, record.MainSubjects, subject_items) {
+				statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, retrieved)
+				qs_writer.Write(statement)
+			}
 
-		main_subjects := ""
-		for idx, subject := range record.MainSubjects {
-			if idx != 0 {
-				main_subjects += "; "
+			// Relations to other publications, such as retractions and errata. Some of these
+			// statements go on the other publication's item rather than this one.
+			for _, relation := range relationStatements(target, record.Relations, pmid_wikidata_items) {
+				if emitted_relations[relation] {
+					continue
+				}
+				// Statements made on another item can't refer back to one we're creating, and
+				// LAST means a different item for each paper, so those aren't worth remembering
+				if target == LAST_ITEM {
+					if relation.Object == LAST_ITEM {
+						continue
+					}
+				} else {
+					emitted_relations[relation] = true
+				}
+				statement = AddItemPropertyToItem(relation.Subject, relation.Property, relation.Object)
+				statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+				statement.AddSource(RETRIEVED_AT_DATE_SOURCE, retrieved)
+				qs_writer.Write(statement)
 			}
-			main_subjects += subject.Name
-			l := drug_wikidata_items[subject.MeshID]
-			if disease_wikidata_items[subject.MeshID] != "" {
-				if l != "" {
-					l += ", "
+
+			// Statements already on wikidata that disagree with PubMed. Those we can remove go in
+			// the QuickStatements file, and all of them are listed for a human to check.
+			if config.Corrections && item != "" {
+				corrections := make([]Correction, 0)
+				if license_item != "" {
+					corrections = append(corrections, licenseCorrections(item, license_item, license_source,
+						existing_statements.Get(item, LICENSE_PROPERTY))...)
+				}
+				if record.IsRetracted {
+					corrections = append(corrections, retractionCorrections(item, record.Relations, pmid_wikidata_items,
+						existing_statements.Get(item, RETRACTED_BY_PROPERTY))...)
+				}
+				for _, correction := range corrections {
+					proposed_corrections += 1
+					if removal := correction.Removal(); removal != nil {
+						qs_writer.Write(removal)
+					}
+					corrections_file.WriteString(fmt.Sprintf("%s\t%s\t%s\t%v\t%s\t%s\t%s\t%v\n",
+						record.PMID, item, correction.Property, correction.CurrentValue(),
+						strings.Join(correction.Expected, ", "), correction.Action, correction.StatedIn, retrieved))
 				}
-				l += disease_wikidata_items[subject.MeshID]
 			}
-			if l != "" {
-				main_subjects += fmt.Sprintf(" (%s)", l)
+
+			qs_writer.EndBlock()
+		}
+
+		// Note the works we couldn't find on wikidata, so they can be added and this run again
+		for _, ref := range record.References {
+			if referenceToWDItem(ref, pmid_wikidata_items, doi_wikidata_items) == "" {
+				unresolved_references += 1
+				refs_file.WriteString(fmt.Sprintf("%s\t%s\t%s\t%s\t%s\n",
+					record.PMID, item, ref.Citation, ref.GetPMID(), ref.GetDOI()))
 			}
 		}
 
+		main_subjects := formatSubjects(record.MainSubjects, subject_items)
+		suggested_subjects := formatSubjects(record.SuggestedTopics, subject_items)
+
 		review_str := "false"
 		if record.IsReview {
 			review_str = "true"
@@ -470,15 +895,28 @@ func batch(term string, ncbi_api_key string, csv_file *os.File, qs_file *os.File
 		}
 
 		retraction_str := "false"
-		if record.IsRetracted {
+		if record.IsRetraction {
 			retraction_str = "true"
 		}
 
-		csv_file.WriteString(fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-			record.Title, item, record.PMID, record.PMCID, record.PMCLicense,
-			record.EPMCLicenseLink, license_item, main_subjects,
-			record.PublicationDate, record.Publication, record.ISSN, issn_item, review_str,
-			retracted_str, record.RetractedByPMID, retracted_by_item, retraction_str))
+		csv_file.WriteString(fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			record.Title, item, record.PMID, record.PMCID, record.DOI, record.PMCLicense,
+			record.EPMCLicenseLink, license_item, main_subjects, suggested_subjects,
+			record.PublicationDate.String(), record.Publication, record.ISSN, issn_item,
+			record.Volume, record.Issue, record.Pages, formatPublicationTypes(record.PubTypes), review_str,
+			retracted_str, record.RetractedByPMID, retracted_by_item, retraction_str,
+			formatOtherRelations(record.Relations), formatGrants(record.Grants, funder_wikidata_items), record.Abstract))
+	}
+
+	log.Printf("Found %d cited works with no wikidata item for %s.\n", unresolved_references, term)
+	if config.Corrections {
+		log.Printf("Proposed %d corrections for %s.\n", proposed_corrections, term)
+	}
+	if created > 0 {
+		log.Printf("Created %d new items for %s.\n", created, term)
+	}
+	if missing > 0 {
+		log.Printf("Found %d articles with no wikidata item for %s that weren't created.\n", missing, term)
 	}
 
 	return nil
@@ -488,13 +926,101 @@ func main() {
 
 	var term_feed_path string
 	var ncbi_api_key string
+	var ncbi_base_url string
+	var ncbi_tool string
+	var ncbi_email string
+	var ncbi_rate float64
+	var ncbi_retries int
+	var ncbi_timeout time.Duration
+	var date_rule string
+	var substance_rule string
+	var publication_types_path string
+	var search_types_list string
+	var funders_path string
+	var create_missing bool
+	var corrections bool
+	var qs_format string
 	flag.StringVar(&term_feed_path, "feed", "", "JSON list of terms to search PMC for.")
 	flag.StringVar(&ncbi_api_key, "ncbi_api_key", "", "NCBI API KEY. Can also be set as NCBI_API_KEY environmental variable.")
+	flag.StringVar(&ncbi_base_url, "ncbi_url", EUtils.DEFAULT_BASE_URL, "Base URL of the NCBI E-utilities, for use with a mirror or proxy.")
+	flag.StringVar(&ncbi_tool, "ncbi_tool", "NCBI2wikidata", "Tool name to identify ourselves to NCBI with.")
+	flag.StringVar(&ncbi_email, "ncbi_email", "", "Contact email address to give to NCBI. Can also be set as NCBI_EMAIL environmental variable.")
+	flag.IntVar(&ncbi_retries, "ncbi_retries", EUtils.DEFAULT_MAX_RETRIES, "How many times to retry NCBI requests that fail for transient reasons.")
+	flag.DurationVar(&ncbi_timeout, "ncbi_timeout", NCBI_REQUEST_TIMEOUT, "How long to wait on a single NCBI request before giving up on it and retrying.")
+	flag.Float64Var(&ncbi_rate, "ncbi_rate", 0, "Maximum NCBI requests per second, if you have negotiated a higher limit. Defaults to NCBI's standard limits.")
+	flag.StringVar(&date_rule, "date_rule", string(EUtils.DEFAULT_DATE_RULE), "Which date to use as the publication date: \"pubdate\" for the journal issue date, \"articledate\" for the electronic publication date, or \"earliest\" for whichever came first.")
+	flag.StringVar(&substance_rule, "substance_subjects", string(DEFAULT_SUBSTANCE_RULE), "Which of the substances and supplementary concepts NLM indexed a paper with to use as main subjects: \"concepts\" for supplementary concepts only, \"all\" for every one, or \"none\". The rest are listed as suggested subjects.")
+	flag.StringVar(&publication_types_path, "publication_types", "", "JSON file mapping NLM publication types to wikidata classes. Defaults to the mapping built in to the tool.")
+	flag.StringVar(&search_types_list, "search_types", DEFAULT_SEARCH_TYPES, "Comma separated list of the publication types to search for, by name or UI.")
+	flag.StringVar(&funders_path, "funders", "", "JSON file mapping funding agency names, as given in PubMed, to wikidata items, for funders that can't be found by name.")
+	flag.BoolVar(&create_missing, "create", false, "Create new wikidata items for papers that aren't on wikidata yet, rather than skipping them.")
+	flag.BoolVar(&corrections, "corrections", false, "Check the license and retraction statements already on wikidata against PubMed, and propose corrections where they disagree.")
+	flag.StringVar(&qs_format, "qs_format", QS_FORMAT_V1, "Format to write the QuickStatements in: \"v1\" for the tab separated commands, or \"csv\" for the CSV format with a row per item.")
 	flag.Parse()
 
+	if !EUtils.DateRule(date_rule).IsValid() {
+		panic(fmt.Errorf("Unknown date rule %s", date_rule))
+	}
+	if !SubstanceRule(substance_rule).IsValid() {
+		panic(fmt.Errorf("Unknown substance subjects rule %s", substance_rule))
+	}
+	if qs_format != QS_FORMAT_V1 && qs_format != QS_FORMAT_CSV {
+		panic(fmt.Errorf("Unknown QuickStatements format %s", qs_format))
+	}
+
 	if ncbi_api_key == "" {
 		ncbi_api_key = os.Getenv("NCBI_API_KEY")
 	}
+	if ncbi_email == "" {
+		ncbi_email = os.Getenv("NCBI_EMAIL")
+	}
+
+	// On Ctrl-C we cancel whatever requests are in flight and stop, keeping the results for the
+	// terms we've already done. A second Ctrl-C kills us outright.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		<-interrupts
+		log.Printf("Interrupted, stopping...")
+		signal.Stop(interrupts)
+		cancel()
+	}()
+
+	client := EUtils.NewClient(ncbi_api_key)
+	client.BaseURL = ncbi_base_url
+	client.Tool = ncbi_tool
+	client.Email = ncbi_email
+	client.RequestsPerSecond = ncbi_rate
+	client.MaxRetries = ncbi_retries
+	client.Timeout = ncbi_timeout
+
+	publication_types, err := LoadPublicationTypes(publication_types_path)
+	if err != nil {
+		panic(err)
+	}
+	search_types, err := publication_types.Select(search_types_list)
+	if err != nil {
+		panic(err)
+	}
+
+	funder_overrides, err := LoadFunderOverrides(funders_path)
+	if err != nil {
+		panic(err)
+	}
+
+	config := BatchConfig{
+		Client:           client,
+		DateRule:         EUtils.DateRule(date_rule),
+		SubstanceRule:    SubstanceRule(substance_rule),
+		PublicationTypes: publication_types,
+		SearchTypes:      search_types,
+		FunderOverrides:  funder_overrides,
+		CreateMissing:    create_missing,
+		Created:          make(map[string]bool, 0),
+		Corrections:      corrections,
+	}
 
 	f, err := os.Open(term_feed_path)
 	if err != nil {
@@ -507,23 +1033,87 @@ func main() {
 		panic(err)
 	}
 
-	qs_file, err := os.Create("results_quickstatements.txt")
+	// Check our search is still valid before we start, as NCBI will just return no results for
+	// fields it doesn't know
+	info_request := EUtils.EInfoRequest{
+		DB: "pubmed",
+	}
+	info_resp, err := info_request.DoWithContext(ctx, client)
+	if err != nil {
+		panic(err)
+	}
+	if len(info_resp.DBInfo) != 1 {
+		panic(fmt.Errorf("Expected info on one database, got %d", len(info_resp.DBInfo)))
+	}
+	err = validateQueryFields(buildSearchQuery("", search_types), info_resp.DBInfo[0])
+	if err != nil {
+		panic(err)
+	}
+
+	qs_filename := "results_quickstatements.txt"
+	if qs_format == QS_FORMAT_CSV {
+		qs_filename = "results_quickstatements.csv"
+	}
+	qs_file, err := os.Create(qs_filename)
 	if err != nil {
 		panic(err)
 	}
 	defer qs_file.Close()
+	qs_writer, err := NewQuickStatementsWriter(qs_format, qs_file)
+	if err != nil {
+		panic(err)
+	}
+	defer flushQuickStatements(qs_writer)
 	csv_file, err := os.Create("results.csv")
 	if err != nil {
 		panic(err)
 	}
 	defer csv_file.Close()
-	csv_file.WriteString("Title\tItem\tPMID\tPMCID\tLicense PMC\tLicense EPMC\tLicense Item\tMain Subjects\tPublication Date\tPublication\tISSN\tISSN item\tIs Review Article\tIs retracted\tRetracted by\tRetacted by item\tIs retraction\n")
+	refs_file, err := os.Create("results_unresolved_references.csv")
+	if err != nil {
+		panic(err)
+	}
+	defer refs_file.Close()
+	var corrections_file *os.File
+	if corrections {
+		corrections_file, err = os.Create("results_corrections.csv")
+		if err != nil {
+			panic(err)
+		}
+		defer corrections_file.Close()
+		corrections_file.WriteString("PMID\tItem\tProperty\tWikidata Value\tPubMed Values\tAction\tStated In\tRetrieved\n")
+	}
+	refs_file.WriteString("Citing PMID\tCiting Item\tCitation\tCited PMID\tCited DOI\n")
+	csv_file.WriteString("Title\tItem\tPMID\tPMCID\tDOI\tLicense PMC\tLicense EPMC\tLicense Item\tMain Subjects\tSuggested Subjects\tPublication Date\tPublication\tISSN\tISSN item\tVolume\tIssue\tPages\tPublication Types\tIs Review Article\tIs retracted\tRetracted by\tRetacted by item\tIs retraction\tOther Relations\tGrants\tAbstract\n")
 
 	for _, term := range term_feed {
-		x := fmt.Sprintf("\"%s\"[Mesh Major Topic] AND (Review[ptyp] OR \"Retraction of Publication\"[PTYP])", term)
-		err := batch(x, ncbi_api_key, csv_file, qs_file)
+		x := buildSearchQuery(term, search_types)
+		err := batch(ctx, x, config, csv_file, qs_writer, refs_file, corrections_file)
+		if ctx.Err() != nil {
+			break
+		}
 		if err != nil {
+			// If NCBI are still having issues after we've retried then give up on this term, but
+			// keep what we've got for the others rather than throw away the whole run
+			if EUtils.IsRetryable(err) {
+				log.Printf("Skipping %s after repeated NCBI failures: %v", term, err)
+				continue
+			}
 			panic(err)
 		}
 	}
+
+	// The CSV writer holds everything until it's flushed, so this must happen before the files are
+	// synced and closed; the deferred flush is only for runs that panic
+	err = qs_writer.Flush()
+	if err != nil {
+		panic(err)
+	}
+
+	if ctx.Err() != nil {
+		qs_file.Sync()
+		csv_file.Sync()
+		refs_file.Sync()
+		log.Printf("Stopped early, results so far are in results.csv, %s, and results_unresolved_references.csv.", qs_filename)
+	}
 }
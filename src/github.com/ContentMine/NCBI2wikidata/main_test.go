@@ -45,6 +45,8 @@ func TestRercordGen(t *testing.T) {
 		IsRetracted      bool
 		IsRetraction     bool
 		RetractedByPMID  string
+		AuthorCount      int
+		PublicationDate  string
 	}{
 		{
 			filename:         "testdata/example1.xml",
@@ -55,6 +57,8 @@ func TestRercordGen(t *testing.T) {
 			IsRetracted:      false,
 			IsRetraction:     false,
 			RetractedByPMID:  "",
+			AuthorCount:      3,
+			PublicationDate:  "+2018-05-28T00:00:00Z/11",
 		},
 		{
 			filename:         "testdata/topics.xml",
@@ -65,16 +69,20 @@ func TestRercordGen(t *testing.T) {
 			IsRetracted:      false,
 			IsRetraction:     false,
 			RetractedByPMID:  "",
+			AuthorCount:      5,
+			PublicationDate:  "+2017-04-12T00:00:00Z/11",
 		},
 		{
 			filename:         "testdata/retracted.xml",
 			PMID:             "27685632",
 			PMCID:            "5059863",
-			MainSubjectCount: 4,
+			MainSubjectCount: 6,
 			IsReview:         false,
 			IsRetracted:      true,
 			IsRetraction:     false,
 			RetractedByPMID:  "30683838",
+			AuthorCount:      8,
+			PublicationDate:  "+2016-09-29T00:00:00Z/11",
 		},
 		{
 			filename:         "testdata/retraction.xml",
@@ -85,6 +93,8 @@ func TestRercordGen(t *testing.T) {
 			IsRetracted:      false,
 			IsRetraction:     true,
 			RetractedByPMID:  "",
+			AuthorCount:      8,
+			PublicationDate:  "+2019-01-25T00:00:00Z/11",
 		},
 	}
 
@@ -103,7 +113,7 @@ func TestRercordGen(t *testing.T) {
 
 		article := article_set.Articles[0]
 
-		record := ArticleToRecord(article)
+		record := ArticleToRecord(article, EUtils.DEFAULT_DATE_RULE, DEFAULT_SUBSTANCE_RULE)
 
 		if record.PMID != testitem.PMID {
 			t.Errorf("PMID in record incorrect: %s not %s", record.PMID, testitem.PMID)
@@ -114,5 +124,11 @@ func TestRercordGen(t *testing.T) {
 		if len(record.MainSubjects) != testitem.MainSubjectCount {
 			t.Errorf("Subject count in record incorrect: %d not %d", len(record.MainSubjects), testitem.MainSubjectCount)
 		}
+		if len(record.Authors) != testitem.AuthorCount {
+			t.Errorf("Author count in record incorrect: %d not %d", len(record.Authors), testitem.AuthorCount)
+		}
+		if record.PublicationDate.String() != testitem.PublicationDate {
+			t.Errorf("Publication date in record incorrect: %s not %s", record.PublicationDate.String(), testitem.PublicationDate)
+		}
 	}
 }
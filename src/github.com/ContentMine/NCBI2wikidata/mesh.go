@@ -0,0 +1,226 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ContentMine/EUtils"
+)
+
+// NLM publish MeSH as linked data, which is the only place we can find out which headings a
+// supplementary concept is mapped to without downloading the whole of MeSH
+const MESH_SPARQL_URL = "https://id.nlm.nih.gov/mesh/sparql"
+const MESH_URI_PREFIX = "http://id.nlm.nih.gov/mesh/"
+
+// A concept can be mapped to a descriptor, or to a descriptor/qualifier pair, in which case we
+// just want the descriptor
+const MESH_MAPPING_QUERY = `PREFIX mesh: <http://id.nlm.nih.gov/mesh/>
+PREFIX meshv: <http://id.nlm.nih.gov/mesh/vocab#>
+SELECT ?val ?res WHERE {
+  VALUES ?val { %s }
+  ?val meshv:preferredMappedTo ?mapped.
+  OPTIONAL { ?mapped meshv:hasDescriptor ?descriptor. }
+  BIND(COALESCE(?descriptor, ?mapped) AS ?res)
+}
+`
+
+func buildMeshMappingQuery(concepts []string) string {
+
+	values := make([]string, len(concepts))
+	for idx, concept := range concepts {
+		values[idx] = "mesh:" + concept
+	}
+	return fmt.Sprintf(MESH_MAPPING_QUERY, strings.Join(values, " "))
+}
+
+func internalGetMeshMappings(ctx context.Context, concepts []string, results map[string][]string) error {
+
+	params := url.Values{}
+	params.Add("query", buildMeshMappingQuery(concepts))
+	params.Add("format", "JSON")
+
+	ctx, cancel := context.WithTimeout(ctx, SPARQL_QUERY_TIMEOUT)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", MESH_SPARQL_URL+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Accept", "application/sparql-results+json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("Status code %d", resp.StatusCode)
+		} else {
+			return fmt.Errorf("Status code %d: %s", resp.StatusCode, body)
+		}
+	}
+
+	data := SparqlResponse{}
+	err = json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		return err
+	}
+
+	for _, binding := range data.Results.Bindings {
+		concept := strings.TrimPrefix(binding.Key.Value, MESH_URI_PREFIX)
+		descriptor := strings.TrimPrefix(binding.Result.Value, MESH_URI_PREFIX)
+		results[concept] = append(results[concept], descriptor)
+	}
+
+	return nil
+}
+
+// Returns the MeSH descriptors each of the given supplementary concepts is mapped to
+func GetMeshMappings(ctx context.Context, concepts []string) (map[string][]string, error) {
+
+	results := make(map[string][]string)
+
+	for i := 0; i < len(concepts); i += MAX_ITEMS_PER_QUERY {
+		j := i + MAX_ITEMS_PER_QUERY
+		if len(concepts) < j {
+			j = len(concepts)
+		}
+
+		err := internalGetMeshMappings(ctx, concepts[i:j], results)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// Which of the substances and supplementary concepts an article was indexed with are used as main
+// subjects. NLM list everything an article mentions using, down to water and buffers, and those
+// are recorded as MeSH descriptors. The supplementary concepts are the specific compounds, rare
+// diseases, and organisms, so by default only those are used, and the rest are just suggested.
+type SubstanceRule string
+
+const SUBSTANCE_RULE_CONCEPTS SubstanceRule = "concepts"
+const SUBSTANCE_RULE_ALL SubstanceRule = "all"
+const SUBSTANCE_RULE_NONE SubstanceRule = "none"
+
+const DEFAULT_SUBSTANCE_RULE = SUBSTANCE_RULE_CONCEPTS
+
+var SUBSTANCE_RULES = []SubstanceRule{SUBSTANCE_RULE_CONCEPTS, SUBSTANCE_RULE_ALL, SUBSTANCE_RULE_NONE}
+
+func (rule SubstanceRule) IsValid() bool {
+	for _, valid := range SUBSTANCE_RULES {
+		if rule == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// Splits an article's substance topics into those to use as main subjects and those to suggest
+func splitSubstanceTopics(topics []EUtils.MeshDescriptorName, rule SubstanceRule) ([]EUtils.MeshDescriptorName, []EUtils.MeshDescriptorName) {
+
+	subjects := make([]EUtils.MeshDescriptorName, 0)
+	suggestions := make([]EUtils.MeshDescriptorName, 0)
+	for _, topic := range topics {
+		switch {
+		case rule == SUBSTANCE_RULE_ALL:
+			subjects = append(subjects, topic)
+		case rule == SUBSTANCE_RULE_CONCEPTS && EUtils.IsSupplementaryConceptID(topic.MeshID):
+			subjects = append(subjects, topic)
+		default:
+			suggestions = append(suggestions, topic)
+		}
+	}
+	return subjects, suggestions
+}
+
+// What we found on wikidata for the MeSH IDs of an article's main subjects
+type SubjectItems struct {
+	Drugs    map[string]string
+	Diseases map[string]string
+	Concepts map[string]string
+	Mappings map[string][]string
+}
+
+// Returns the wikidata items for a main subject. If a supplementary concept isn't on wikidata
+// itself, we fall back to the headings it is mapped to, which are more general but better than
+// nothing.
+func (s SubjectItems) Items(mesh_id string) []string {
+
+	items := s.directItems(mesh_id)
+	if len(items) == 0 {
+		for _, descriptor := range s.Mappings[mesh_id] {
+			for _, item := range s.directItems(descriptor) {
+				items = appendUniqueItem(items, item)
+			}
+		}
+	}
+	return items
+}
+
+func (s SubjectItems) directItems(mesh_id string) []string {
+
+	items := make([]string, 0)
+	for _, lookup := range []map[string]string{s.Drugs, s.Diseases, s.Concepts} {
+		if lookup[mesh_id] != "" {
+			items = appendUniqueItem(items, lookup[mesh_id])
+		}
+	}
+	return items
+}
+
+// Makes the main subject statements for an article, without sources. Each notes the MeSH name it
+// came from as "subject named as", so it's clear why it was added, particularly where a concept
+// fell back to a broader heading. A concept can fall back to the same heading as a major topic,
+// so each item is only given once, named as the first subject that led to it.
+func mainSubjectStatements(item string, subjects []EUtils.MeshDescriptorName, subject_items SubjectItems) []*AddStatement {
+
+	statements := make([]*AddStatement, 0)
+	given := make(map[string]bool)
+	for _, subject := range subjects {
+		for _, subject_item := range subject_items.Items(subject.MeshID) {
+			if given[subject_item] {
+				continue
+			}
+			given[subject_item] = true
+			statement := AddItemPropertyToItem(item, MAIN_SUBJECT_PROPERTY, subject_item)
+			statement.AddQualifier(SUBJECT_NAMED_AS_QUALIFIER, StringValue(subject.Name))
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
+
+func appendUniqueItem(items []string, item string) []string {
+	for _, existing := range items {
+		if existing == item {
+			return items
+		}
+	}
+	return append(items, item)
+}
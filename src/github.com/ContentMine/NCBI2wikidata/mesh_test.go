@@ -0,0 +1,136 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ContentMine/EUtils"
+)
+
+func TestBuildMeshMappingQuery(t *testing.T) {
+
+	query := buildMeshMappingQuery([]string{"C538231", "C000623205"})
+	if !strings.Contains(query, "VALUES ?val { mesh:C538231 mesh:C000623205 }") {
+		t.Errorf("Expected concepts to be listed: %s", query)
+	}
+}
+
+func TestSplitSubstanceTopics(t *testing.T) {
+
+	topics := []EUtils.MeshDescriptorName{
+		{Name: "Water", MeshID: "D014867"},
+		{Name: "MIRN623 microRNA, human", MeshID: "C000623205"},
+		{Name: "Adenocarcinoma of lung", MeshID: "C538231"},
+	}
+
+	testdata := []struct {
+		rule        SubstanceRule
+		subjects    int
+		suggestions int
+	}{
+		{SUBSTANCE_RULE_CONCEPTS, 2, 1},
+		{SUBSTANCE_RULE_ALL, 3, 0},
+		{SUBSTANCE_RULE_NONE, 0, 3},
+	}
+
+	for _, testitem := range testdata {
+		subjects, suggestions := splitSubstanceTopics(topics, testitem.rule)
+		if len(subjects) != testitem.subjects || len(suggestions) != testitem.suggestions {
+			t.Errorf("%s: expected %d subjects and %d suggestions, got %v and %v", testitem.rule,
+				testitem.subjects, testitem.suggestions, subjects, suggestions)
+		}
+	}
+
+	subjects, suggestions := splitSubstanceTopics(topics, DEFAULT_SUBSTANCE_RULE)
+	if subjects[0].MeshID != "C000623205" || suggestions[0].MeshID != "D014867" {
+		t.Errorf("Expected concepts as subjects and descriptors as suggestions, got %v and %v", subjects, suggestions)
+	}
+
+	if SubstanceRule("some").IsValid() || !SUBSTANCE_RULE_NONE.IsValid() {
+		t.Errorf("Substance rule validation is wrong")
+	}
+}
+
+func TestSubjectItems(t *testing.T) {
+
+	subject_items := SubjectItems{
+		Drugs:    map[string]string{"D000001": "Q1"},
+		Diseases: map[string]string{"D000001": "Q2", "D000077192": "Q3"},
+		Concepts: map[string]string{"C000001": "Q4"},
+		Mappings: map[string][]string{
+			"C000001": {"D000001"},
+			"C538231": {"D000077192", "D000230"},
+			"C000002": {"D000077192"},
+		},
+	}
+
+	testdata := []struct {
+		mesh_id  string
+		expected string
+	}{
+		{"D000001", "Q1,Q2"},
+		{"C000001", "Q4"},
+		{"C538231", "Q3"},
+		{"C000003", ""},
+	}
+
+	for _, testitem := range testdata {
+		items := strings.Join(subject_items.Items(testitem.mesh_id), ",")
+		if items != testitem.expected {
+			t.Errorf("%s: expected %s, got %s", testitem.mesh_id, testitem.expected, items)
+		}
+	}
+
+	formatted := formatSubjects([]EUtils.MeshDescriptorName{{Name: "Adenocarcinoma of lung", MeshID: "C538231"}, {Name: "Other", MeshID: "D000002"}}, subject_items)
+	if formatted != "Adenocarcinoma of lung (Q3); Other" {
+		t.Errorf("Unexpected formatted subjects: %s", formatted)
+	}
+}
+
+func TestMainSubjectStatements(t *testing.T) {
+
+	subject_items := SubjectItems{
+		Diseases: map[string]string{"D000077192": "Q3", "D008288": "Q12156"},
+		Mappings: map[string][]string{"C538231": {"D000077192"}},
+	}
+	subjects := []EUtils.MeshDescriptorName{
+		{Name: "Malaria", MeshID: "D008288"},
+		{Name: "Adenocarcinoma of Lung", MeshID: "D000077192"},
+		{Name: "Adenocarcinoma of lung", MeshID: "C538231"},
+		{Name: "Unknown", MeshID: "D000001"},
+	}
+
+	statements := mainSubjectStatements("Q1", subjects, subject_items)
+	expected := []string{
+		"Q1\tP921\tQ12156\tP1810\t\"Malaria\"\n",
+		"Q1\tP921\tQ3\tP1810\t\"Adenocarcinoma of Lung\"\n",
+	}
+	if len(statements) != len(expected) {
+		t.Fatalf("Expected %d statements, got %d", len(expected), len(statements))
+	}
+	for idx, statement := range statements {
+		if statement.String() != expected[idx] {
+			t.Errorf("Expected %q, got %q", expected[idx], statement.String())
+		}
+	}
+
+	// Qualifiers have to come before the sources
+	statements[0].AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+	if statements[0].String() != "Q1\tP921\tQ12156\tP1810\t\"Malaria\"\tS248\tQ180686\n" {
+		t.Errorf("Unexpected statement with source: %q", statements[0].String())
+	}
+}
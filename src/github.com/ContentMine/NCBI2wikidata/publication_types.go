@@ -0,0 +1,176 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ContentMine/EUtils"
+)
+
+// The mapping we ship with, used unless another file is given with -publication_types
+//
+//go:embed publication_types.json
+var DEFAULT_PUBLICATION_TYPES []byte
+
+// The publication types we search for if none are given with -search_types
+const DEFAULT_SEARCH_TYPES = "Review,Systematic Review,Retraction of Publication"
+
+// Maps one of NLM's publication types, identified by its unique ID, e.g., D016454, to the class on
+// wikidata that articles of that type are an instance of. Item is empty for types that have no
+// class for the article itself, e.g., clinical trials, where the wikidata items describe the trial
+// and not the paper reporting it; these can still be searched for
+type PublicationTypeMapping struct {
+	UI   string `json:"ui"`
+	Name string `json:"name"`
+	Item string `json:"item"`
+}
+
+type PublicationTypeTable struct {
+	Types []PublicationTypeMapping
+	by_ui map[string]PublicationTypeMapping
+}
+
+func LoadPublicationTypes(filename string) (PublicationTypeTable, error) {
+
+	if filename == "" {
+		return ReadPublicationTypes(strings.NewReader(string(DEFAULT_PUBLICATION_TYPES)))
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return PublicationTypeTable{}, err
+	}
+	defer f.Close()
+	return ReadPublicationTypes(f)
+}
+
+func ReadPublicationTypes(r io.Reader) (PublicationTypeTable, error) {
+
+	var types []PublicationTypeMapping
+	err := json.NewDecoder(r).Decode(&types)
+	if err != nil {
+		return PublicationTypeTable{}, err
+	}
+
+	table := PublicationTypeTable{
+		Types: types,
+		by_ui: make(map[string]PublicationTypeMapping, len(types)),
+	}
+	for _, mapping := range types {
+		if !strings.HasPrefix(mapping.UI, "D") {
+			return PublicationTypeTable{}, fmt.Errorf("Publication type %s has an invalid UI %s", mapping.Name, mapping.UI)
+		}
+		if mapping.Item != "" && !strings.HasPrefix(mapping.Item, "Q") {
+			return PublicationTypeTable{}, fmt.Errorf("Publication type %s has an invalid item %s", mapping.Name, mapping.Item)
+		}
+		if _, ok := table.by_ui[mapping.UI]; ok {
+			return PublicationTypeTable{}, fmt.Errorf("Publication type %s is listed more than once", mapping.UI)
+		}
+		table.by_ui[mapping.UI] = mapping
+	}
+	return table, nil
+}
+
+// Finds a publication type by either its UI or its name, ignoring case for the name
+func (table PublicationTypeTable) Lookup(name_or_ui string) (PublicationTypeMapping, bool) {
+
+	if mapping, ok := table.by_ui[name_or_ui]; ok {
+		return mapping, true
+	}
+	for _, mapping := range table.Types {
+		if strings.EqualFold(mapping.Name, name_or_ui) {
+			return mapping, true
+		}
+	}
+	return PublicationTypeMapping{}, false
+}
+
+// Takes a comma separated list of publication type names or UIs, as given on the command line, and
+// returns the matching types from the table
+func (table PublicationTypeTable) Select(list string) ([]PublicationTypeMapping, error) {
+
+	selected := make([]PublicationTypeMapping, 0)
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		mapping, ok := table.Lookup(part)
+		if !ok {
+			return nil, fmt.Errorf("Unknown publication type %s", part)
+		}
+		selected = append(selected, mapping)
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("No publication types selected")
+	}
+	return selected, nil
+}
+
+// Returns the wikidata classes for the publication types given, skipping those we have no mapping
+// or no class for, and only listing each class once
+func (table PublicationTypeTable) Items(types []EUtils.PublicationType) []string {
+
+	items := make([]string, 0, len(types))
+	seen := make(map[string]bool)
+	for _, pubtype := range types {
+		mapping, ok := table.by_ui[pubtype.UI]
+		if !ok || mapping.Item == "" || seen[mapping.Item] {
+			continue
+		}
+		seen[mapping.Item] = true
+		items = append(items, mapping.Item)
+	}
+	return items
+}
+
+// Builds the PubMed search for a term, restricted to articles of any of the given types
+func buildSearchQuery(term string, types []PublicationTypeMapping) string {
+
+	clauses := make([]string, len(types))
+	for idx, mapping := range types {
+		clauses[idx] = fmt.Sprintf(PUBLICATION_TYPE_QUERY_TEMPLATE, mapping.Name)
+	}
+	return fmt.Sprintf(SEARCH_QUERY_TEMPLATE, term, strings.Join(clauses, " OR "))
+}
+
+// The search should only find articles of the types asked for, but PubMed matches type names in a
+// search more loosely than we map them, so we check each article against the UIs too
+func matchesPublicationTypes(types []EUtils.PublicationType, selected []PublicationTypeMapping) bool {
+
+	for _, pubtype := range types {
+		for _, mapping := range selected {
+			if pubtype.UI == mapping.UI {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func formatPublicationTypes(types []EUtils.PublicationType) string {
+
+	names := make([]string, len(types))
+	for idx, pubtype := range types {
+		names[idx] = pubtype.Type
+	}
+	return strings.Join(names, "; ")
+}
@@ -0,0 +1,151 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ContentMine/EUtils"
+)
+
+func TestDefaultPublicationTypes(t *testing.T) {
+
+	table, err := LoadPublicationTypes("")
+	if err != nil {
+		t.Fatalf("Failed to load default publication types: %v", err)
+	}
+
+	review, ok := table.Lookup("D016454")
+	if !ok || review.Name != "Review" || review.Item != "Q7318358" {
+		t.Errorf("Unexpected mapping for review: %v", review)
+	}
+	retraction, ok := table.Lookup("retraction of publication")
+	if !ok || retraction.UI != "D016440" {
+		t.Errorf("Unexpected mapping for retraction: %v", retraction)
+	}
+
+	trial, ok := table.Lookup("Clinical Trial, Phase III")
+	if !ok || trial.Item != "" {
+		t.Errorf("Expected clinical trials to have no class, got %v", trial)
+	}
+
+	selected, err := table.Select(DEFAULT_SEARCH_TYPES)
+	if err != nil {
+		t.Fatalf("Failed to select default search types: %v", err)
+	}
+	if len(selected) != 3 {
+		t.Errorf("Expected 3 default search types, got %d", len(selected))
+	}
+}
+
+func TestReadPublicationTypesInvalid(t *testing.T) {
+
+	testdata := []string{
+		`[{"ui": "D016454", "name": "Review", "item": "Q7318358"}, {"ui": "D016454", "name": "Review", "item": "Q7318358"}]`,
+		`[{"ui": "16454", "name": "Review", "item": "Q7318358"}]`,
+		`[{"ui": "D016454", "name": "Review", "item": "review article"}]`,
+		`{"ui": "D016454"}`,
+		`[{"ui": "D016430", "name": "Clinical Trial", "item": "clinical trial"}]`,
+	}
+
+	for _, data := range testdata {
+		_, err := ReadPublicationTypes(strings.NewReader(data))
+		if err == nil {
+			t.Errorf("Expected error reading %s", data)
+		}
+	}
+}
+
+func TestSelectPublicationTypes(t *testing.T) {
+
+	table, err := LoadPublicationTypes("")
+	if err != nil {
+		t.Fatalf("Failed to load default publication types: %v", err)
+	}
+
+	selected, err := table.Select("Case Reports, D017418")
+	if err != nil {
+		t.Fatalf("Failed to select types: %v", err)
+	}
+	if len(selected) != 2 || selected[0].UI != "D002363" || selected[1].Name != "Meta-Analysis" {
+		t.Errorf("Unexpected selection: %v", selected)
+	}
+
+	query := buildSearchQuery("Malaria", selected)
+	expected := "\"Malaria\"[Mesh Major Topic] AND (\"Case Reports\"[ptyp] OR \"Meta-Analysis\"[ptyp])"
+	if query != expected {
+		t.Errorf("Unexpected query %s, expected %s", query, expected)
+	}
+
+	_, err = table.Select("Review, Not A Type")
+	if err == nil {
+		t.Errorf("Expected error selecting an unknown type")
+	}
+	_, err = table.Select(" , ")
+	if err == nil {
+		t.Errorf("Expected error selecting no types")
+	}
+}
+
+func TestPublicationTypeItems(t *testing.T) {
+
+	table, err := LoadPublicationTypes("")
+	if err != nil {
+		t.Fatalf("Failed to load default publication types: %v", err)
+	}
+	search_types, err := table.Select(DEFAULT_SEARCH_TYPES)
+	if err != nil {
+		t.Fatalf("Failed to select default search types: %v", err)
+	}
+
+	testdata := []struct {
+		filename string
+		items    []string
+		matches  bool
+	}{
+		{"testdata/example1.xml", []string{SCHOLARLY_ARTICLE_TYPE, "Q7318358"}, true},
+		{"testdata/retracted.xml", []string{SCHOLARLY_ARTICLE_TYPE, "Q45182324"}, false},
+		{"testdata/retraction.xml", []string{"Q1348305", "Q7316896"}, true},
+	}
+
+	for _, testitem := range testdata {
+		article_set, err := loadXML(testitem.filename)
+		if err != nil {
+			t.Errorf("Failed to load test data: %v", err)
+			continue
+		}
+		pubtypes := article_set.Articles[0].GetPublicationTypes()
+
+		items := table.Items(pubtypes)
+		if strings.Join(items, ",") != strings.Join(testitem.items, ",") {
+			t.Errorf("%s: expected items %v, got %v", testitem.filename, testitem.items, items)
+		}
+		if matchesPublicationTypes(pubtypes, search_types) != testitem.matches {
+			t.Errorf("%s: expected match to be %v", testitem.filename, testitem.matches)
+		}
+	}
+
+	// Clinical trial types have no class of their own, so only the journal article class is used
+	pubtypes := []EUtils.PublicationType{
+		{Type: "Journal Article", UI: "D016428"},
+		{Type: "Randomized Controlled Trial", UI: "D016449"},
+		{Type: "Clinical Trial, Phase II", UI: "D017427"},
+	}
+	items := table.Items(pubtypes)
+	if strings.Join(items, ",") != SCHOLARLY_ARTICLE_TYPE {
+		t.Errorf("Expected only the journal article class for a clinical trial, got %v", items)
+	}
+}
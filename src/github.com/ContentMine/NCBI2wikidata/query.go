@@ -15,6 +15,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -22,6 +23,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 type Head struct {
@@ -54,6 +56,10 @@ const MAX_ITEMS_PER_QUERY = 200
 
 const SPARQL_QUERY_URL = "https://query.wikidata.org/sparql"
 
+// query.wikidata.org stops queries after 60 seconds, so if we've heard nothing back after this
+// long the connection is most likely stuck
+const SPARQL_QUERY_TIMEOUT = 90 * time.Second
+
 const QUERY_HEADER = `SELECT ?res ?val WHERE {
 `
 const QUERY_BODY = `
@@ -62,11 +68,21 @@ const QUERY_BODY = `
     ?res wdt:%s "%s".
   }
 `
+
+// For lookups by an identifier that is unique enough that we don't need to check the item type
+const QUERY_BODY_UNTYPED = `
+  {
+    ?res wdt:%s "%s".
+  }
+`
 const QUERY_FOOTER = `
   OPTIONAL { ?res wdt:%s ?val. }
 }
 `
 
+// Some identifiers, DOIs in particular, can contain characters that would end the string early
+var sparqlStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+
 func buildSparqlQuery(key string, values []string, item_type string) string {
 
 	query := QUERY_HEADER
@@ -74,24 +90,89 @@ func buildSparqlQuery(key string, values []string, item_type string) string {
 		if idx != 0 {
 			query += " UNION "
 		}
-		query += fmt.Sprintf(QUERY_BODY, item_type, key, val)
+		if item_type == "" {
+			query += fmt.Sprintf(QUERY_BODY_UNTYPED, key, sparqlStringEscaper.Replace(val))
+		} else {
+			query += fmt.Sprintf(QUERY_BODY, item_type, key, sparqlStringEscaper.Replace(val))
+		}
 	}
 	query += fmt.Sprintf(QUERY_FOOTER, key)
 
 	return query
 }
 
-func internalGetItemsFromWikiData(key string, values []string, item_type string, results map[string]string) error {
+// Funders are found by name, as that's all PubMed gives us. Labels and aliases are both checked,
+// and we only accept items that are an instance of one of the given types.
+const LABEL_QUERY = `SELECT ?res ?val WHERE {
+  VALUES ?val { %s }
+  VALUES ?type { %s }
+  { ?res rdfs:label ?val. } UNION { ?res skos:altLabel ?val. }
+  ?res wdt:P31 ?type.
+}
+`
+
+// Common names match a lot of labels, so we look up fewer of them at once than we do identifiers
+const MAX_LABELS_PER_QUERY = 50
+
+func buildLabelSparqlQuery(labels []string, item_types []string) string {
+
+	values := make([]string, len(labels))
+	for idx, label := range labels {
+		values[idx] = fmt.Sprintf("\"%s\"@en", sparqlStringEscaper.Replace(label))
+	}
+	types := make([]string, len(item_types))
+	for idx, item_type := range item_types {
+		types[idx] = "wd:" + item_type
+	}
+	return fmt.Sprintf(LABEL_QUERY, strings.Join(values, " "), strings.Join(types, " "))
+}
+
+func internalGetItemsFromWikiData(ctx context.Context, key string, values []string, item_type string, results map[string]string) error {
 
 	// If we're not given anything don't bother the server
 	if len(values) == 0 {
 		return nil
 	}
 
+	return runSparqlQuery(ctx, buildSparqlQuery(key, values, item_type), key, results)
+}
+
+// Runs a query that returns the matching item as res and what it matched on as val, adding them
+// to results
+func runSparqlQuery(ctx context.Context, query string, key string, results map[string]string) error {
+
+	data := SparqlResponse{}
+	err := fetchSparqlResults(ctx, query, &data)
+	if err != nil {
+		return err
+	}
+
+	for _, binding := range data.Results.Bindings {
+
+		// In theory we whouldn't get multiple matches for the things we're looking up
+		// (i.e., each PMCID should give us just one paper item back). Due to mistakes that might
+		// not be true, so we just log when we hit issues
+		val := strings.TrimPrefix(binding.Result.Value, "http://www.wikidata.org/entity/")
+		if results[binding.Key.Value] != "" && results[binding.Key.Value] != val {
+			log.Printf("Found duplicate wikidata result for %s with %s", key, binding.Key.Value)
+		} else {
+			results[binding.Key.Value] = val
+		}
+	}
+
+	return nil
+}
+
+// Sends a query to query.wikidata.org, decoding the JSON results into data
+func fetchSparqlResults(ctx context.Context, query string, data interface{}) error {
+
 	params := url.Values{}
-	params.Add("query", buildSparqlQuery(key, values, item_type))
+	params.Add("query", query)
+
+	ctx, cancel := context.WithTimeout(ctx, SPARQL_QUERY_TIMEOUT)
+	defer cancel()
 
-	req, err := http.NewRequest("POST", SPARQL_QUERY_URL, strings.NewReader(params.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", SPARQL_QUERY_URL, strings.NewReader(params.Encode()))
 	if err != nil {
 		return err
 	}
@@ -114,64 +195,115 @@ func internalGetItemsFromWikiData(key string, values []string, item_type string,
 		}
 	}
 
-	data := SparqlResponse{}
-	err = json.NewDecoder(resp.Body).Decode(&data)
-	if err != nil {
-		return err
+	return json.NewDecoder(resp.Body).Decode(data)
+}
+
+func GetItemsFromWikiData(key string, values []string, item_type string) (map[string]string, error) {
+	return GetItemsFromWikiDataWithContext(context.Background(), key, values, item_type)
+}
+
+// Like GetItemsFromWikiData, but gives up once the context is cancelled
+func GetItemsFromWikiDataWithContext(ctx context.Context, key string, values []string, item_type string) (map[string]string, error) {
+
+	results := make(map[string]string)
+
+	for i := 0; i < len(values); i += MAX_ITEMS_PER_QUERY {
+		j := i + MAX_ITEMS_PER_QUERY
+		if len(values) < j {
+			j = len(values)
+		}
+		s := values[i:j]
+
+		err := internalGetItemsFromWikiData(ctx, key, s, item_type, results)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	for _, binding := range data.Results.Bindings {
+	return results, nil
+}
 
-		// In theory we whouldn't get multiple matches for the things we're looking up
-		// (i.e., each PMCID should give us just one paper item back). Due to mistakes that might
-		// not be true, so we just log when we hit issues
+func PMCIDsToWDItem(ctx context.Context, pmcids []string) (map[string]string, error) {
+	return GetItemsFromWikiDataWithContext(ctx, PMCID_PROPERTY, pmcids, SCHOLARLY_ARTICLE_TYPE)
+}
+
+func PMIDsToWDItem(ctx context.Context, pmcids []string) (map[string]string, error) {
+	return GetItemsFromWikiDataWithContext(ctx, PMID_PROPERTY, pmcids, SCHOLARLY_ARTICLE_TYPE)
+}
+
+func DOIsToWDItem(ctx context.Context, dois []string) (map[string]string, error) {
+	return GetItemsFromWikiDataWithContext(ctx, DOI_PROPERTY, dois, SCHOLARLY_ARTICLE_TYPE)
+}
+
+// Maps each name to the item it matched, leaving out names that matched more than one item, as
+// we can't tell which of them was meant
+func uniqueLabelMatches(bindings []Binding) map[string]string {
+
+	matches := make(map[string]map[string]bool)
+	for _, binding := range bindings {
 		val := strings.TrimPrefix(binding.Result.Value, "http://www.wikidata.org/entity/")
-		if results[binding.Key.Value] != "" && results[binding.Key.Value] != val {
-			log.Printf("Found duplicate wikidata result for %s with %s", key, binding.Key.Value)
-		} else {
-			results[binding.Key.Value] = val
+		if matches[binding.Key.Value] == nil {
+			matches[binding.Key.Value] = make(map[string]bool)
 		}
+		matches[binding.Key.Value][val] = true
 	}
 
-	return nil
+	results := make(map[string]string)
+	for name, items := range matches {
+		if len(items) != 1 {
+			log.Printf("Found %d wikidata items for funder %s, leaving it unresolved", len(items), name)
+			continue
+		}
+		for item := range items {
+			results[name] = item
+		}
+	}
+	return results
 }
 
-func GetItemsFromWikiData(key string, values []string, item_type string) (map[string]string, error) {
+// Looks up funders by name. Unlike the other lookups, names aren't unique, so names that match
+// more than one item are left out of the results.
+func FundersToWDItem(ctx context.Context, agencies []string) (map[string]string, error) {
 
 	results := make(map[string]string)
 
-	for i := 0; i < len(values); i += MAX_ITEMS_PER_QUERY {
-		j := i + MAX_ITEMS_PER_QUERY
-		if len(values) < j {
-			j = len(values)
+	for i := 0; i < len(agencies); i += MAX_LABELS_PER_QUERY {
+		j := i + MAX_LABELS_PER_QUERY
+		if len(agencies) < j {
+			j = len(agencies)
 		}
-		s := values[i:j]
 
-		err := internalGetItemsFromWikiData(key, s, item_type, results)
+		data := SparqlResponse{}
+		err := fetchSparqlResults(ctx, buildLabelSparqlQuery(agencies[i:j], FUNDER_TYPES), &data)
 		if err != nil {
 			return nil, err
 		}
+		for name, item := range uniqueLabelMatches(data.Results.Bindings) {
+			results[name] = item
+		}
 	}
 
 	return results, nil
 }
 
-func PMCIDsToWDItem(pmcids []string) (map[string]string, error) {
-	return GetItemsFromWikiData(PMCID_PROPERTY, pmcids, SCHOLARLY_ARTICLE_TYPE)
+// Supplementary concepts cover all sorts of things, from compounds to rare diseases, so unlike
+// drugs and diseases we accept whatever has the MeSH ID
+func SupplementaryConceptsToWDItem(ctx context.Context, meshids []string) (map[string]string, error) {
+	return GetItemsFromWikiDataWithContext(ctx, MESH_ID_PROPERTY, meshids, "")
 }
 
-func PMIDsToWDItem(pmcids []string) (map[string]string, error) {
-	return GetItemsFromWikiData(PMID_PROPERTY, pmcids, SCHOLARLY_ARTICLE_TYPE)
+func ORCIDsToWDItem(ctx context.Context, orcids []string) (map[string]string, error) {
+	return GetItemsFromWikiDataWithContext(ctx, ORCID_PROPERTY, orcids, HUMAN_TYPE)
 }
 
-func ISSNsToWDItem(issn []string) (map[string]string, error) {
-	return GetItemsFromWikiData(ISSN_PROPERTY, issn, SCIENTIFIC_JOURNAL_TYPE)
+func ISSNsToWDItem(ctx context.Context, issn []string) (map[string]string, error) {
+	return GetItemsFromWikiDataWithContext(ctx, ISSN_PROPERTY, issn, SCIENTIFIC_JOURNAL_TYPE)
 }
 
-func DrugsToWDItem(meshids []string) (map[string]string, error) {
-	return GetItemsFromWikiData(MESH_ID_PROPERTY, meshids, DRUG_TYPE)
+func DrugsToWDItem(ctx context.Context, meshids []string) (map[string]string, error) {
+	return GetItemsFromWikiDataWithContext(ctx, MESH_ID_PROPERTY, meshids, DRUG_TYPE)
 }
 
-func DiseasesToWDItem(meshids []string) (map[string]string, error) {
-	return GetItemsFromWikiData(MESH_ID_PROPERTY, meshids, DISEASE_TYPE)
+func DiseasesToWDItem(ctx context.Context, meshids []string) (map[string]string, error) {
+	return GetItemsFromWikiDataWithContext(ctx, MESH_ID_PROPERTY, meshids, DISEASE_TYPE)
 }
@@ -0,0 +1,72 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildSparqlQueryEscaping(t *testing.T) {
+
+	query := buildSparqlQuery(DOI_PROPERTY, []string{"10.1000/ABC\"DEF", "10.1000/XYZ"}, SCHOLARLY_ARTICLE_TYPE)
+
+	if !strings.Contains(query, `?res wdt:P356 "10.1000/ABC\"DEF".`) {
+		t.Errorf("Expected quote in DOI to be escaped: %s", query)
+	}
+	if strings.Count(query, "UNION") != 1 {
+		t.Errorf("Expected one UNION for two values: %s", query)
+	}
+}
+
+func TestBuildLabelSparqlQuery(t *testing.T) {
+
+	query := buildLabelSparqlQuery([]string{"Wellcome Trust", "Bill \"&\" Melinda"}, []string{ORGANIZATION_TYPE, GOVERNMENT_AGENCY_TYPE})
+
+	if !strings.Contains(query, `VALUES ?val { "Wellcome Trust"@en "Bill \"&\" Melinda"@en }`) {
+		t.Errorf("Expected labels to be listed and escaped: %s", query)
+	}
+	if !strings.Contains(query, "VALUES ?type { wd:Q43229 wd:Q327333 }") {
+		t.Errorf("Expected results to be restricted to organisations: %s", query)
+	}
+	if strings.Contains(query, "P279") {
+		t.Errorf("Expected types to be matched directly, not through subclasses: %s", query)
+	}
+}
+
+func TestUniqueLabelMatches(t *testing.T) {
+
+	binding := func(item string, name string) Binding {
+		return Binding{
+			Result: Result{Type: "uri", Value: "http://www.wikidata.org/entity/" + item},
+			Key:    Result{Type: "literal", Value: name},
+		}
+	}
+	bindings := []Binding{
+		binding("Q1", "Wellcome Trust"),
+		binding("Q1", "Wellcome Trust"),
+		binding("Q2", "Medical Research Council"),
+		binding("Q3", "Medical Research Council"),
+		binding("Q4", "Cancer Research UK"),
+	}
+
+	results := uniqueLabelMatches(bindings)
+	if len(results) != 2 || results["Wellcome Trust"] != "Q1" || results["Cancer Research UK"] != "Q4" {
+		t.Errorf("Unexpected matches: %v", results)
+	}
+	if _, ok := results["Medical Research Council"]; ok {
+		t.Errorf("Expected a name matching two items to be left unresolved: %v", results)
+	}
+}
@@ -16,46 +16,233 @@ package main
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ContentMine/EUtils"
 )
 
+// A value in a QuickStatements command. Each kind of value wikidata supports is written
+// differently, so we keep track of what kind each is until we come to write it out.
+type Value interface {
+	String() string
+}
+
+// Double quotes are escaped so they aren't taken as the end of the string, and tabs and newlines
+// so they aren't taken as the end of the column or command. Backslashes are escaped too, so a
+// backslash in the text can't be mistaken for the start of one of those.
+var quickStatementsStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+func quoteString(value string) string {
+	return "\"" + quickStatementsStringEscaper.Replace(value) + "\""
+}
+
+// An item, such as "Q5"
+type ItemValue string
+
+func (v ItemValue) String() string {
+	return string(v)
+}
+
+type StringValue string
+
+func (v StringValue) String() string {
+	return quoteString(string(v))
+}
+
+// External identifiers, such as PMCIDs and DOIs, are written the same way as strings
+type ExternalIDValue string
+
+func (v ExternalIDValue) String() string {
+	return quoteString(string(v))
+}
+
+type URLValue string
+
+func (v URLValue) String() string {
+	return quoteString(string(v))
+}
+
+type MonolingualTextValue struct {
+	Language string
+	Text     string
+}
+
+func (v MonolingualTextValue) String() string {
+	return fmt.Sprintf("%s:%s", v.Language, quoteString(v.Text))
+}
+
+// Dates are assumed to be in the Gregorian calendar unless marked as Julian
+const CALENDAR_GREGORIAN = ""
+const CALENDAR_JULIAN = "J"
+
+// A date known to the given precision, using wikidata's precision values, e.g., 11 for a day
+type TimeValue struct {
+	Year      int
+	Month     int
+	Day       int
+	Precision int
+	Calendar  string
+}
+
+func (v TimeValue) String() string {
+	formatted := fmt.Sprintf("+%04d-%02d-%02dT00:00:00Z/%d", v.Year, v.Month, v.Day, v.Precision)
+	if v.Calendar != CALENDAR_GREGORIAN {
+		formatted += "/" + v.Calendar
+	}
+	return formatted
+}
+
+// Returns the day the given time falls on, such as for when we retrieved something
+func DateValue(t time.Time) TimeValue {
+	return TimeValue{
+		Year:      t.Year(),
+		Month:     int(t.Month()),
+		Day:       t.Day(),
+		Precision: EUtils.DATE_PRECISION_DAY,
+	}
+}
+
+func PublicationDateValue(d EUtils.PublicationDate) TimeValue {
+	return TimeValue{
+		Year:      d.Year,
+		Month:     d.Month,
+		Day:       d.Day,
+		Precision: d.Precision,
+	}
+}
+
+// An amount, with the item for its unit if it has one
+type QuantityValue struct {
+	Amount float64
+	Unit   string
+}
+
+func (v QuantityValue) String() string {
+	quantity := strconv.FormatFloat(v.Amount, 'f', -1, 64)
+	if v.Unit != "" {
+		quantity += "U" + strings.TrimPrefix(v.Unit, "Q")
+	}
+	return quantity
+}
+
+// For when there is a value but we don't know what it is, e.g., an author we can't name
+type SomeValue struct{}
+
+func (v SomeValue) String() string {
+	return "somevalue"
+}
+
+// For when we know there is no value, e.g., an article that has no authors
+type NoValue struct{}
+
+func (v NoValue) String() string {
+	return "novalue"
+}
+
 type Source struct {
 	ID    string
-	Value string
+	Value Value
+}
+
+type Qualifier struct {
+	ID    string
+	Value Value
 }
 
 type AddStatement struct {
-	ItemID     string
-	PropertyID string
-	Value      string
-	SourceList []Source
+	ItemID        string
+	PropertyID    string
+	Value         Value
+	QualifierList []Qualifier
+	SourceList    []Source
 }
 
+// QuickStatements takes the qualifiers as pairs of columns after the value, and then the sources,
+// whose property IDs are written with an S rather than a P
 func (a *AddStatement) String() string {
-	statement := fmt.Sprintf("%s\t%s\t%s", a.ItemID, a.PropertyID, a.Value)
+	statement := fmt.Sprintf("%s\t%s\t%v", a.ItemID, a.PropertyID, a.Value)
+	for _, qualifier := range a.QualifierList {
+		statement = fmt.Sprintf("%s\t%s\t%v", statement, qualifier.ID, qualifier.Value)
+	}
 	for _, source := range a.SourceList {
-		statement = fmt.Sprintf("%s\t%s\t%s", statement, source.ID, source.Value)
+		statement = fmt.Sprintf("%s\t%s\t%v", statement, source.ID, source.Value)
 	}
 	return statement + "\n"
 }
 
-func AddItemPropertyToItem(target_id string, property_id string, value_id string) *AddStatement {
+func AddPropertyToItem(target_id string, property_id string, value Value) *AddStatement {
 	return &AddStatement{
 		ItemID:     target_id,
 		PropertyID: property_id,
-		Value:      value_id,
+		Value:      value,
 		SourceList: make([]Source, 0),
 	}
 }
 
+func AddItemPropertyToItem(target_id string, property_id string, value_id string) *AddStatement {
+	return AddPropertyToItem(target_id, property_id, ItemValue(value_id))
+}
+
 func AddStringPropertyToItem(target_id string, property_id string, value string) *AddStatement {
-	return &AddStatement{
-		ItemID:     target_id,
-		PropertyID: property_id,
-		Value:      value,
-		SourceList: make([]Source, 0),
-	}
+	return AddPropertyToItem(target_id, property_id, StringValue(value))
+}
+
+func (a *AddStatement) AddQualifier(qualifier_id string, value Value) {
+	a.QualifierList = append(a.QualifierList, Qualifier{ID: qualifier_id, Value: value})
 }
 
-func (a *AddStatement) AddSource(source_id, value string) {
+func (a *AddStatement) AddSource(source_id string, value Value) {
 	a.SourceList = append(a.SourceList, Source{ID: source_id, Value: value})
 }
+
+// Statements made after a CREATE apply to the new item if given LAST as their item
+const LAST_ITEM = "LAST"
+
+type CreateStatement struct{}
+
+func (c *CreateStatement) String() string {
+	return "CREATE\n"
+}
+
+// Labels, descriptions, and aliases are written with the language code after the letter, e.g.,
+// "Len" for an English label
+const LABEL_TERM = "L"
+const DESCRIPTION_TERM = "D"
+const ALIAS_TERM = "A"
+
+type TermStatement struct {
+	ItemID   string
+	Term     string
+	Language string
+	Text     string
+}
+
+func (t *TermStatement) String() string {
+	return fmt.Sprintf("%s\t%s%s\t%s\n", t.ItemID, t.Term, t.Language, quoteString(t.Text))
+}
+
+func SetLabel(target_id string, language string, text string) *TermStatement {
+	return &TermStatement{ItemID: target_id, Term: LABEL_TERM, Language: language, Text: text}
+}
+
+func SetDescription(target_id string, language string, text string) *TermStatement {
+	return &TermStatement{ItemID: target_id, Term: DESCRIPTION_TERM, Language: language, Text: text}
+}
+
+// Removes a statement from an item, written as the statement would be with a minus in front. Only
+// the value is needed to find the statement, so removals don't take qualifiers or sources.
+type RemoveStatement struct {
+	ItemID     string
+	PropertyID string
+	Value      Value
+}
+
+func (r *RemoveStatement) String() string {
+	return fmt.Sprintf("-%s\t%s\t%v\n", r.ItemID, r.PropertyID, r.Value)
+}
+
+func RemovePropertyFromItem(target_id string, property_id string, value Value) *RemoveStatement {
+	return &RemoveStatement{ItemID: target_id, PropertyID: property_id, Value: value}
+}
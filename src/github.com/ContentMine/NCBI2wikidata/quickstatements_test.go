@@ -0,0 +1,79 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ContentMine/EUtils"
+)
+
+func TestStatementQualifiers(t *testing.T) {
+
+	statement := AddStringPropertyToItem("Q1", AUTHOR_NAME_STRING_PROPERTY, "Thales De Brito")
+	statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+	statement.AddQualifier(SERIES_ORDINAL_QUALIFIER, StringValue("1"))
+
+	expected := "Q1\tP2093\t\"Thales De Brito\"\tP1545\t\"1\"\tS248\tQ180686\n"
+	if statement.String() != expected {
+		t.Errorf("Unexpected statement: %q", statement.String())
+	}
+}
+
+func TestValues(t *testing.T) {
+
+	testdata := []struct {
+		value    Value
+		expected string
+	}{
+		{ItemValue("Q5"), "Q5"},
+		{StringValue("Thales De Brito"), "\"Thales De Brito\""},
+		{StringValue("A \"quoted\" title"), "\"A \\\"quoted\\\" title\""},
+		{StringValue("A tabbed\tand\r\nsplit title"), "\"A tabbed\\tand\\r\\nsplit title\""},
+		{StringValue(`C:\path`), `"C:\\path"`},
+		{ExternalIDValue("5975557"), "\"5975557\""},
+		{ExternalIDValue("10.1590/S1678-9946201860023"), "\"10.1590/S1678-9946201860023\""},
+		{URLValue("https://creativecommons.org/licenses/by/4.0/"), "\"https://creativecommons.org/licenses/by/4.0/\""},
+		{MonolingualTextValue{Language: "en", Text: "Leptospirosis"}, "en:\"Leptospirosis\""},
+		{TimeValue{Year: 2018, Month: 5, Day: 28, Precision: 11}, "+2018-05-28T00:00:00Z/11"},
+		{TimeValue{Year: 1582, Month: 10, Day: 4, Precision: 11, Calendar: CALENDAR_JULIAN}, "+1582-10-04T00:00:00Z/11/J"},
+		{PublicationDateValue(EUtils.PublicationDate{Year: 2018, Month: 3, Precision: EUtils.DATE_PRECISION_MONTH}), "+2018-03-00T00:00:00Z/10"},
+		{DateValue(time.Date(2019, 2, 3, 14, 0, 0, 0, time.UTC)), "+2019-02-03T00:00:00Z/11"},
+		{QuantityValue{Amount: 12}, "12"},
+		{QuantityValue{Amount: 1.5, Unit: "Q11573"}, "1.5U11573"},
+		{QuantityValue{Amount: -3, Unit: "Q11570"}, "-3U11570"},
+		{SomeValue{}, "somevalue"},
+		{NoValue{}, "novalue"},
+	}
+
+	for _, testitem := range testdata {
+		if testitem.value.String() != testitem.expected {
+			t.Errorf("Expected %s, got %s", testitem.expected, testitem.value.String())
+		}
+	}
+}
+
+func TestStatementWithTypedValues(t *testing.T) {
+
+	statement := AddPropertyToItem("Q1", PUBLICATION_DATE_PROPERTY, TimeValue{Year: 2018, Precision: EUtils.DATE_PRECISION_YEAR})
+	statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+	statement.AddSource(RETRIEVED_AT_DATE_SOURCE, TimeValue{Year: 2019, Month: 1, Day: 2, Precision: EUtils.DATE_PRECISION_DAY})
+
+	expected := "Q1\tP577\t+2018-00-00T00:00:00Z/9\tS248\tQ180686\tS813\t+2019-01-02T00:00:00Z/11\n"
+	if statement.String() != expected {
+		t.Errorf("Unexpected statement: %q", statement.String())
+	}
+}
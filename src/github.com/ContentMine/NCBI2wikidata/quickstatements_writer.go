@@ -0,0 +1,263 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+// Anything that can go in a QuickStatements batch: an AddStatement, RemoveStatement,
+// CreateStatement, or TermStatement. String gives the command in the V1 format.
+type Command interface {
+	String() string
+}
+
+const QS_FORMAT_V1 = "v1"
+const QS_FORMAT_CSV = "csv"
+
+// Writes commands out in one of the formats QuickStatements accepts. EndBlock is called after the
+// commands for each paper, and Flush once everything has been written.
+type QuickStatementsWriter interface {
+	Write(command Command)
+	EndBlock()
+	Flush() error
+}
+
+func NewQuickStatementsWriter(format string, w io.Writer) (QuickStatementsWriter, error) {
+	switch format {
+	case QS_FORMAT_V1:
+		return &V1Writer{w: w}, nil
+	case QS_FORMAT_CSV:
+		return NewCSVWriter(w), nil
+	default:
+		return nil, fmt.Errorf("Unknown QuickStatements format %s", format)
+	}
+}
+
+// The CSV format can't be written until we have every row, so this is deferred as soon as the
+// writer is made. That way a run that panics part way through still leaves the QuickStatements
+// for the papers done so far, as the V1 format would. A normal run flushes before it closes the
+// file, and then this does nothing.
+func flushQuickStatements(writer QuickStatementsWriter) {
+	err := writer.Flush()
+	if err != nil {
+		log.Printf("Failed to write QuickStatements: %v", err)
+	}
+}
+
+// The V1 format has one command per line as we go, with a blank line between papers to make it
+// easier to read
+type V1Writer struct {
+	w io.Writer
+}
+
+func (v *V1Writer) Write(command Command) {
+	io.WriteString(v.w, command.String())
+}
+
+func (v *V1Writer) EndBlock() {
+	io.WriteString(v.w, "\n")
+}
+
+func (v *V1Writer) Flush() error {
+	return nil
+}
+
+// The CSV format has a row per item, with a column for each statement, followed by columns for its
+// qualifiers and sources. An empty qid creates a new item. The header names every column, e.g.,
+// "qid,Len,P31,S248,S813,P2093,qal1545,S248,S813", and as there is only one header for the whole
+// file, all the rows have to be collected before any can be written.
+//
+// Each item's statements are grouped into one row, so a paper's row will have as many author
+// columns as the paper with the most authors. Statements on LAST go in the row of the item most
+// recently created, as there's no other way to refer to it.
+type CSVWriter struct {
+	w       io.Writer
+	rows    []*csvRow
+	by_qid  map[string]*csvRow
+	last    *csvRow
+	flushed bool
+}
+
+// A statement's columns, and their values. Statements with the same columns are written under the
+// same headers, so the headers joined together say which columns a statement can go in.
+type csvStatement struct {
+	headers []string
+	values  []string
+}
+
+func (s csvStatement) key() string {
+	return strings.Join(s.headers, ",")
+}
+
+type csvRow struct {
+	qid        string
+	statements []csvStatement
+}
+
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{
+		w:      w,
+		rows:   make([]*csvRow, 0),
+		by_qid: make(map[string]*csvRow, 0),
+	}
+}
+
+func (c *CSVWriter) newRow(qid string) *csvRow {
+	row := &csvRow{qid: qid, statements: make([]csvStatement, 0)}
+	c.rows = append(c.rows, row)
+	return row
+}
+
+func (c *CSVWriter) row(item_id string) *csvRow {
+
+	if item_id == LAST_ITEM {
+		// LAST with nothing created would be an error in V1, so make it a new item here
+		if c.last == nil {
+			c.last = c.newRow("")
+		}
+		return c.last
+	}
+
+	row, ok := c.by_qid[item_id]
+	if !ok {
+		row = c.newRow(item_id)
+		c.by_qid[item_id] = row
+	}
+	return row
+}
+
+// Qualifiers are written as "qal" and the property number, e.g., "qal1545" for P1545
+func qualifierHeader(qualifier_id string) string {
+	return "qal" + strings.TrimPrefix(qualifier_id, "P")
+}
+
+func (c *CSVWriter) Write(command Command) {
+
+	switch cmd := command.(type) {
+	case *CreateStatement:
+		c.last = c.newRow("")
+
+	case *TermStatement:
+		// Unlike string values, labels and descriptions aren't quoted in the CSV format
+		row := c.row(cmd.ItemID)
+		row.statements = append(row.statements, csvStatement{
+			headers: []string{cmd.Term + cmd.Language},
+			values:  []string{strings.Join(strings.Fields(cmd.Text), " ")},
+		})
+
+	case *AddStatement:
+		statement := csvStatement{
+			headers: []string{cmd.PropertyID},
+			values:  []string{cmd.Value.String()},
+		}
+		for _, qualifier := range cmd.QualifierList {
+			statement.headers = append(statement.headers, qualifierHeader(qualifier.ID))
+			statement.values = append(statement.values, qualifier.Value.String())
+		}
+		for _, source := range cmd.SourceList {
+			statement.headers = append(statement.headers, source.ID)
+			statement.values = append(statement.values, source.Value.String())
+		}
+		row := c.row(cmd.ItemID)
+		row.statements = append(row.statements, statement)
+
+	case *RemoveStatement:
+		row := c.row(cmd.ItemID)
+		row.statements = append(row.statements, csvStatement{
+			headers: []string{"-" + cmd.PropertyID},
+			values:  []string{cmd.Value.String()},
+		})
+	}
+}
+
+// Rows are grouped by item rather than by paper, so blocks don't matter here
+func (c *CSVWriter) EndBlock() {
+}
+
+// Works out the columns needed, which is enough of each kind of statement for the row that has the
+// most of them, in the order each was first seen, and then writes out all the rows. Only the first
+// call writes anything, as the header can only be written once.
+func (c *CSVWriter) Flush() error {
+
+	if c.flushed {
+		return nil
+	}
+	c.flushed = true
+
+	slot_keys := make([]string, 0)
+	slot_headers := make(map[string][]string, 0)
+	slot_counts := make(map[string]int, 0)
+
+	for _, row := range c.rows {
+		counts := make(map[string]int, 0)
+		for _, statement := range row.statements {
+			key := statement.key()
+			counts[key] += 1
+			if _, ok := slot_headers[key]; !ok {
+				slot_keys = append(slot_keys, key)
+				slot_headers[key] = statement.headers
+			}
+			if counts[key] > slot_counts[key] {
+				slot_counts[key] = counts[key]
+			}
+		}
+	}
+
+	header := []string{"qid"}
+	for _, key := range slot_keys {
+		for i := 0; i < slot_counts[key]; i++ {
+			header = append(header, slot_headers[key]...)
+		}
+	}
+
+	writer := csv.NewWriter(c.w)
+	err := writer.Write(header)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range c.rows {
+
+		by_key := make(map[string][]csvStatement, 0)
+		for _, statement := range row.statements {
+			by_key[statement.key()] = append(by_key[statement.key()], statement)
+		}
+
+		record := []string{row.qid}
+		for _, key := range slot_keys {
+			statements := by_key[key]
+			for i := 0; i < slot_counts[key]; i++ {
+				if i < len(statements) {
+					record = append(record, statements[i].values...)
+				} else {
+					record = append(record, make([]string, len(slot_headers[key]))...)
+				}
+			}
+		}
+
+		err = writer.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
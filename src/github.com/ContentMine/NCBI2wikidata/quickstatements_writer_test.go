@@ -0,0 +1,144 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func testCommands() []Command {
+
+	commands := make([]Command, 0)
+
+	statement := AddStringPropertyToItem("Q1", AUTHOR_NAME_STRING_PROPERTY, "Thales De Brito")
+	statement.AddQualifier(SERIES_ORDINAL_QUALIFIER, StringValue("1"))
+	statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+	commands = append(commands, statement)
+
+	statement = AddStringPropertyToItem("Q1", AUTHOR_NAME_STRING_PROPERTY, "Ana Maria Silva")
+	statement.AddQualifier(SERIES_ORDINAL_QUALIFIER, StringValue("2"))
+	statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+	commands = append(commands, statement)
+
+	commands = append(commands, RemovePropertyFromItem("Q1", LICENSE_PROPERTY, ItemValue("Q6905323")))
+
+	commands = append(commands, &CreateStatement{})
+	commands = append(commands, SetLabel(LAST_ITEM, "en", "Leptospirosis, a review"))
+
+	statement = AddItemPropertyToItem(LAST_ITEM, INSTANCE_OF_PROPERTY, SCHOLARLY_ARTICLE_TYPE)
+	statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+	commands = append(commands, statement)
+
+	statement = AddStringPropertyToItem(LAST_ITEM, AUTHOR_NAME_STRING_PROPERTY, "Thales De Brito")
+	statement.AddQualifier(SERIES_ORDINAL_QUALIFIER, StringValue("1"))
+	statement.AddSource(STATED_IN_SOURCE, ItemValue(PM_ITEM))
+	commands = append(commands, statement)
+
+	// A statement on an item we already have a row for goes in that row
+	commands = append(commands, AddItemPropertyToItem("Q1", INSTANCE_OF_PROPERTY, SCHOLARLY_ARTICLE_TYPE))
+
+	return commands
+}
+
+func TestV1Writer(t *testing.T) {
+
+	var buffer bytes.Buffer
+	writer, err := NewQuickStatementsWriter(QS_FORMAT_V1, &buffer)
+	if err != nil {
+		t.Fatalf("Failed to make writer: %v", err)
+	}
+	for _, command := range testCommands()[:3] {
+		writer.Write(command)
+	}
+	writer.EndBlock()
+	err = writer.Flush()
+	if err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	expected := "Q1\tP2093\t\"Thales De Brito\"\tP1545\t\"1\"\tS248\tQ180686\n" +
+		"Q1\tP2093\t\"Ana Maria Silva\"\tP1545\t\"2\"\tS248\tQ180686\n" +
+		"-Q1\tP275\tQ6905323\n\n"
+	if buffer.String() != expected {
+		t.Errorf("Unexpected output: %q", buffer.String())
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+
+	var buffer bytes.Buffer
+	writer, err := NewQuickStatementsWriter(QS_FORMAT_CSV, &buffer)
+	if err != nil {
+		t.Fatalf("Failed to make writer: %v", err)
+	}
+	for _, command := range testCommands() {
+		writer.Write(command)
+	}
+	writer.EndBlock()
+	err = writer.Flush()
+	if err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	expected := "qid,P2093,qal1545,S248,P2093,qal1545,S248,-P275,P31,Len,P31,S248\n" +
+		"Q1,\"\"\"Thales De Brito\"\"\",\"\"\"1\"\"\",Q180686,\"\"\"Ana Maria Silva\"\"\",\"\"\"2\"\"\",Q180686,Q6905323,Q13442814,,,\n" +
+		",\"\"\"Thales De Brito\"\"\",\"\"\"1\"\"\",Q180686,,,,,,\"Leptospirosis, a review\",Q13442814,Q180686\n"
+	if buffer.String() != expected {
+		t.Errorf("Unexpected output:\n%s\nexpected:\n%s", buffer.String(), expected)
+	}
+
+	// main flushes before closing the file and again in a defer, which mustn't repeat the rows
+	err = writer.Flush()
+	if err != nil {
+		t.Fatalf("Failed to flush again: %v", err)
+	}
+	if buffer.String() != expected {
+		t.Errorf("Expected a second flush to write nothing, got:\n%s", buffer.String())
+	}
+}
+
+// A run that panics part way through should still write out what it had
+func TestFlushOnPanic(t *testing.T) {
+
+	var buffer bytes.Buffer
+	writer := NewCSVWriter(&buffer)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected a panic")
+			}
+		}()
+		defer flushQuickStatements(writer)
+
+		writer.Write(AddItemPropertyToItem("Q1", INSTANCE_OF_PROPERTY, SCHOLARLY_ARTICLE_TYPE))
+		writer.EndBlock()
+		panic("failed part way through")
+	}()
+
+	expected := "qid,P31\nQ1,Q13442814\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected the rows so far to be written, got %q", buffer.String())
+	}
+}
+
+func TestUnknownWriterFormat(t *testing.T) {
+
+	_, err := NewQuickStatementsWriter("v3", &bytes.Buffer{})
+	if err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
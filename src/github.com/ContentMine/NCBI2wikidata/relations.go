@@ -0,0 +1,93 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ContentMine/EUtils"
+)
+
+// A statement linking two publications on wikidata
+type relationStatement struct {
+	Subject  string
+	Property string
+	Object   string
+}
+
+// How a relation between two publications is modelled on wikidata. Normally the statement goes on
+// the article that has the relation, pointing at the related publication, but if Reversed it goes
+// on the related publication, pointing back.
+type relationMapping struct {
+	Property string
+	Reversed bool
+}
+
+// The relations we know how to model on wikidata. A retraction notice has the article it retracts
+// as its main subject, and the article is retracted by the notice. Likewise an erratum has the
+// article it corrects as its main subject, and the article links to the erratum. As we may only
+// have one side of each pair in a batch, we make the statements in both directions from either
+// side.
+var RELATION_MAPPINGS = map[string][]relationMapping{
+	EUtils.REF_TYPE_RETRACTION_IN: {{RETRACTED_BY_PROPERTY, false}, {MAIN_SUBJECT_PROPERTY, true}},
+	EUtils.REF_TYPE_RETRACTION_OF: {{MAIN_SUBJECT_PROPERTY, false}, {RETRACTED_BY_PROPERTY, true}},
+	EUtils.REF_TYPE_ERRATUM_IN:    {{ERRATUM_PROPERTY, false}, {MAIN_SUBJECT_PROPERTY, true}},
+	EUtils.REF_TYPE_ERRATUM_FOR:   {{MAIN_SUBJECT_PROPERTY, false}, {ERRATUM_PROPERTY, true}},
+}
+
+func isMappedRelation(link EUtils.CommentsCorrections) bool {
+	_, ok := RELATION_MAPPINGS[link.RefType]
+	return ok
+}
+
+// Returns the statements for the relations we can model, for those where the related publication
+// is on wikidata
+func relationStatements(item string, links []EUtils.CommentsCorrections, pmid_items map[string]string) []relationStatement {
+
+	statements := make([]relationStatement, 0)
+	for _, link := range links {
+		related_item := pmid_items[link.PMID]
+		if link.PMID == "" || related_item == "" {
+			continue
+		}
+		for _, mapping := range RELATION_MAPPINGS[link.RefType] {
+			if mapping.Reversed {
+				statements = append(statements, relationStatement{related_item, mapping.Property, item})
+			} else {
+				statements = append(statements, relationStatement{item, mapping.Property, related_item})
+			}
+		}
+	}
+	return statements
+}
+
+// Lists the relations we can't model on wikidata, for a human to look at, e.g.,
+// "CommentIn 12345678; UpdateOf Lancet. 2018;391(10120):e1"
+func formatOtherRelations(links []EUtils.CommentsCorrections) string {
+
+	parts := make([]string, 0)
+	for _, link := range links {
+		if isMappedRelation(link) {
+			continue
+		}
+		related := link.PMID
+		if related == "" {
+			related = link.RefSource
+		}
+		parts = append(parts, fmt.Sprintf("%s %s", link.RefType, related))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,121 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/ContentMine/EUtils"
+)
+
+func TestRelationStatements(t *testing.T) {
+
+	pmid_items := map[string]string{
+		"30683838": "Q100",
+		"27685632": "Q200",
+		"29972465": "Q300",
+	}
+
+	testdata := []struct {
+		filename   string
+		item       string
+		statements []relationStatement
+		other      string
+	}{
+		{
+			filename: "testdata/example1.xml",
+			item:     "Q1",
+			statements: []relationStatement{
+				{"Q1", ERRATUM_PROPERTY, "Q300"},
+				{"Q300", MAIN_SUBJECT_PROPERTY, "Q1"},
+			},
+		},
+		{
+			filename: "testdata/retracted.xml",
+			item:     "Q200",
+			statements: []relationStatement{
+				{"Q100", MAIN_SUBJECT_PROPERTY, "Q200"},
+				{"Q200", RETRACTED_BY_PROPERTY, "Q100"},
+			},
+		},
+		{
+			filename: "testdata/retraction.xml",
+			item:     "Q100",
+			statements: []relationStatement{
+				{"Q100", MAIN_SUBJECT_PROPERTY, "Q200"},
+				{"Q200", RETRACTED_BY_PROPERTY, "Q100"},
+			},
+		},
+	}
+
+	for _, testitem := range testdata {
+		article_set, err := loadXML(testitem.filename)
+		if err != nil {
+			t.Errorf("Failed to load test data: %v", err)
+			continue
+		}
+		links := article_set.Articles[0].GetCommentsCorrections()
+
+		// Both sides of the retraction should make the same statements, in whatever order
+		statements := relationStatements(testitem.item, links, pmid_items)
+		found := make(map[relationStatement]bool)
+		for _, statement := range statements {
+			found[statement] = true
+		}
+		for _, expected := range testitem.statements {
+			if !found[expected] {
+				t.Errorf("%s: missing statement %v in %v", testitem.filename, expected, statements)
+			}
+		}
+		if len(statements) != len(testitem.statements) {
+			t.Errorf("%s: unexpected statements %v", testitem.filename, statements)
+		}
+	}
+}
+
+// The erratum's side of the link should make the same statements as the article's
+func TestErratumForStatements(t *testing.T) {
+
+	links := []EUtils.CommentsCorrections{{RefType: EUtils.REF_TYPE_ERRATUM_FOR, PMID: "29972465"}}
+	statements := relationStatements("Q400", links, map[string]string{"29972465": "Q1"})
+
+	expected := []relationStatement{
+		{"Q400", MAIN_SUBJECT_PROPERTY, "Q1"},
+		{"Q1", ERRATUM_PROPERTY, "Q400"},
+	}
+	if len(statements) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, statements)
+	}
+	for idx, statement := range statements {
+		if statement != expected[idx] {
+			t.Errorf("Expected %v, got %v", expected[idx], statement)
+		}
+	}
+}
+
+func TestFormatOtherRelations(t *testing.T) {
+
+	links := []EUtils.CommentsCorrections{
+		{RefType: EUtils.REF_TYPE_RETRACTION_IN, PMID: "1"},
+		{RefType: EUtils.REF_TYPE_COMMENT_IN, PMID: "2"},
+		{RefType: EUtils.REF_TYPE_UPDATE_OF, RefSource: "Lancet. 2018;391(10120):e1"},
+	}
+
+	formatted := formatOtherRelations(links)
+	expected := "CommentIn 2; UpdateOf Lancet. 2018;391(10120):e1"
+	if formatted != expected {
+		t.Errorf("Expected %s, got %s", expected, formatted)
+	}
+}
@@ -0,0 +1,82 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ContentMine/EUtils"
+)
+
+// MeSH check tags and age groups are added to almost every paper, so would always be suggested if
+// the abstract mentions people or animals. They're never a useful main subject, so we skip them.
+var CHECK_TAG_MESH_IDS = map[string]bool{
+	"D006801": true, // Humans
+	"D000818": true, // Animals
+	"D008297": true, // Male
+	"D005260": true, // Female
+	"D011247": true, // Pregnancy
+	"D007223": true, // Infant
+	"D007231": true, // Infant, Newborn
+	"D002675": true, // Child, Preschool
+	"D002648": true, // Child
+	"D000293": true, // Adolescent
+	"D055815": true, // Young Adult
+	"D000328": true, // Adult
+	"D008875": true, // Middle Aged
+	"D000368": true, // Aged
+	"D000369": true, // Aged, 80 and over
+}
+
+// MeSH descriptors are often inverted, e.g., "Rats, Wistar", so we also look for them the way
+// they'd be written in prose
+func meshNameVariants(name string) []string {
+
+	variants := []string{name}
+	parts := strings.Split(name, ", ")
+	if len(parts) == 2 {
+		variants = append(variants, parts[1]+" "+parts[0])
+	}
+	return variants
+}
+
+// Suggests extra main subjects for a paper: the MeSH descriptors NLM indexed it with but didn't
+// mark as major topics, that are nonetheless mentioned by name in the title or abstract. These
+// are only suggestions for a human to review, as being mentioned doesn't make something the
+// subject of a paper.
+func suggestMainSubjects(title string, abstract string, minor_topics []EUtils.MeshDescriptorName) []EUtils.MeshDescriptorName {
+
+	text := title + " " + abstract
+	suggestions := make([]EUtils.MeshDescriptorName, 0)
+
+	for _, topic := range minor_topics {
+		if CHECK_TAG_MESH_IDS[topic.MeshID] {
+			continue
+		}
+		for _, variant := range meshNameVariants(topic.Name) {
+			matcher, err := regexp.Compile(`(?i)\b` + regexp.QuoteMeta(variant) + `\b`)
+			if err != nil {
+				continue
+			}
+			if matcher.MatchString(text) {
+				suggestions = append(suggestions, topic)
+				break
+			}
+		}
+	}
+
+	return suggestions
+}
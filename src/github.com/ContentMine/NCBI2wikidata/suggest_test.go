@@ -0,0 +1,79 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/ContentMine/EUtils"
+)
+
+func TestSuggestMainSubjectsFromFixtures(t *testing.T) {
+
+	testdata := []struct {
+		filename    string
+		suggestions []string
+	}{
+		{filename: "testdata/example1.xml", suggestions: []string{}},
+		{filename: "testdata/topics.xml", suggestions: []string{"D015994", "D008296"}},
+		{filename: "testdata/retracted.xml", suggestions: []string{"D049109"}},
+		{filename: "testdata/retraction.xml", suggestions: []string{}},
+	}
+
+	for _, testitem := range testdata {
+
+		article_set, err := loadXML(testitem.filename)
+		if err != nil {
+			t.Errorf("Failed to load test data: %v", err)
+			continue
+		}
+
+		record := ArticleToRecord(article_set.Articles[0], EUtils.DEFAULT_DATE_RULE, DEFAULT_SUBSTANCE_RULE)
+		if len(record.SuggestedTopics) != len(testitem.suggestions) {
+			t.Errorf("%s: expected %d suggestions, got %v", testitem.filename, len(testitem.suggestions), record.SuggestedTopics)
+			continue
+		}
+		for idx, mesh_id := range testitem.suggestions {
+			if record.SuggestedTopics[idx].MeshID != mesh_id {
+				t.Errorf("%s: expected suggestion %s, got %s", testitem.filename, mesh_id, record.SuggestedTopics[idx].MeshID)
+			}
+		}
+		if record.Abstract == "" && testitem.filename != "testdata/retraction.xml" {
+			t.Errorf("%s: expected abstract in record", testitem.filename)
+		}
+	}
+}
+
+func TestSuggestMainSubjectsMatching(t *testing.T) {
+
+	topics := []EUtils.MeshDescriptorName{
+		{Name: "Rats, Wistar", MeshID: "D017208"},
+		{Name: "Humans", MeshID: "D006801"},
+		{Name: "Rain", MeshID: "D011891"},
+		{Name: "Floods", MeshID: "D055864"},
+	}
+
+	suggestions := suggestMainSubjects("A study in Wistar rats", "Humans were infected after heavy rainfall and floods.", topics)
+
+	if len(suggestions) != 2 {
+		t.Fatalf("Expected 2 suggestions, got %v", suggestions)
+	}
+	if suggestions[0].MeshID != "D017208" {
+		t.Errorf("Expected inverted name to match, got %v", suggestions[0])
+	}
+	if suggestions[1].MeshID != "D055864" {
+		t.Errorf("Expected whole word match only, got %v", suggestions[1])
+	}
+}
@@ -0,0 +1,89 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ContentMine/EUtils"
+)
+
+const MESH_MAJOR_TOPIC_FIELD = "MeSH Major Topic"
+
+// PubMed accepts a number of short tags that aren't listed as field names by EInfo, so we
+// map them here to the field they stand for. Tags aren't case sensitive, so these are looked up
+// in lower case.
+var PUBMED_FIELD_TAG_ALIASES = map[string]string{
+	"mh":   "MESH",
+	"majr": "MAJR",
+	"pt":   "PTYP",
+	"ti":   "TITL",
+	"au":   "AUTH",
+	"dp":   "PDAT",
+	"ta":   "JOUR",
+	"la":   "LANG",
+	"sh":   "SUBH",
+	"pmid": "UID",
+}
+
+var fieldTagRegexp = regexp.MustCompile(`\[([^\]]+)\]`)
+
+// Returns the list of field tags, e.g., "Mesh Major Topic", used in a search query
+func extractFieldTags(query string) []string {
+
+	tags := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, match := range fieldTagRegexp.FindAllStringSubmatch(query, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			tags = append(tags, match[1])
+		}
+	}
+	return tags
+}
+
+// Checks that every field tag in the query is one that the database supports, as otherwise the
+// search will just silently return no results.
+func validateQueryFields(query string, info EUtils.EInfoDBInfo) error {
+
+	for _, tag := range extractFieldTags(query) {
+		if _, ok := info.GetField(tag); ok {
+			continue
+		}
+		if alias, ok := PUBMED_FIELD_TAG_ALIASES[strings.ToLower(tag)]; ok {
+			if _, ok := info.GetField(alias); ok {
+				continue
+			}
+		}
+		return fmt.Errorf("Search field [%s] is not supported by %s", tag, info.DBName)
+	}
+	return nil
+}
+
+// Returns true if PubMed matched at least one article against the term as a MeSH major topic. If
+// the term isn't a MeSH heading PubMed will drop it from the translation or find nothing for it.
+func foundAsMeshTerm(result *EUtils.ESearchResult) bool {
+
+	for _, item := range result.GetTranslatedTerms(MESH_MAJOR_TOPIC_FIELD) {
+		count, err := strconv.Atoi(item.Count)
+		if err == nil && count > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+//   Copyright 2019 Content Mine Ltd
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/ContentMine/EUtils"
+)
+
+func loadJSON(filename string, target interface{}) error {
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewDecoder(f).Decode(target)
+}
+
+func TestValidateQueryFields(t *testing.T) {
+
+	var resp EUtils.EInfoResponse
+	err := loadJSON("testdata/einfo.json", &resp)
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+	info := resp.Result.DBInfo[0]
+
+	testdata := []struct {
+		query string
+		valid bool
+	}{
+		{query: SEARCH_QUERY_TEMPLATE, valid: true},
+		{query: buildSearchQuery("Malaria", []PublicationTypeMapping{{UI: "D016454", Name: "Review"}}), valid: true},
+		{query: "\"%s\"[mh] AND Review[pt]", valid: true},
+		{query: "\"%s\"[MH] AND Review[PT]", valid: true},
+		{query: "\"%s\"[Majr] AND malaria[TIAB]", valid: true},
+		{query: "\"%s\"[MH] AND malaria[TW]", valid: false},
+		{query: "\"%s\"[Mesh Major Topics]", valid: false},
+		{query: "\"%s\"[Mesh Major Topic] AND Review[publication]", valid: false},
+	}
+
+	for _, testitem := range testdata {
+		err := validateQueryFields(testitem.query, info)
+		if testitem.valid && err != nil {
+			t.Errorf("Expected %s to be valid: %v", testitem.query, err)
+		}
+		if !testitem.valid && err == nil {
+			t.Errorf("Expected %s to be invalid", testitem.query)
+		}
+	}
+}
+
+func TestFoundAsMeshTerm(t *testing.T) {
+
+	testdata := []struct {
+		filename string
+		found    bool
+	}{
+		{filename: "testdata/esearch.json", found: true},
+		{filename: "testdata/esearch_notfound.json", found: false},
+	}
+
+	for _, testitem := range testdata {
+		var resp EUtils.ESearchResponse
+		err := loadJSON(testitem.filename, &resp)
+		if err != nil {
+			t.Errorf("Failed to load test data: %v", err)
+			continue
+		}
+
+		if foundAsMeshTerm(resp.Result) != testitem.found {
+			t.Errorf("Expected MeSH term found to be %v for %s", testitem.found, testitem.filename)
+		}
+	}
+}
@@ -18,10 +18,29 @@ package main
 const CC_LICENSE_TYPE = "Q284742"
 const SCHOLARLY_ARTICLE_TYPE = "Q13442814"
 const SCIENTIFIC_JOURNAL_TYPE = "Q5633421"
-const RETRACTED_PAPER_TYPE = "Q45182324"
-const RETRACTION_NOTICE_TYPE = "Q7316896"
 const DISEASE_TYPE = "Q12136"
 const DRUG_TYPE = "Q8386"
+const HUMAN_TYPE = "Q5"
+const ORGANIZATION_TYPE = "Q43229"
+const GOVERNMENT_AGENCY_TYPE = "Q327333"
+const FOUNDATION_TYPE = "Q157031"
+const CHARITABLE_ORGANIZATION_TYPE = "Q708676"
+const NONPROFIT_ORGANIZATION_TYPE = "Q163740"
+const RESEARCH_INSTITUTE_TYPE = "Q31855"
+const UNIVERSITY_TYPE = "Q3918"
+
+// The kinds of organisation that fund research. Funders are matched on these directly rather than
+// on any subclass of organisation, as following P279 over every organisation with a matching
+// name is too slow for query.wikidata.org.
+var FUNDER_TYPES = []string{
+	ORGANIZATION_TYPE,
+	GOVERNMENT_AGENCY_TYPE,
+	FOUNDATION_TYPE,
+	CHARITABLE_ORGANIZATION_TYPE,
+	NONPROFIT_ORGANIZATION_TYPE,
+	RESEARCH_INSTITUTE_TYPE,
+	UNIVERSITY_TYPE,
+}
 
 const INSTANCE_OF_PROPERTY = "P31"
 const ISSN_PROPERTY = "P236"
@@ -33,7 +52,25 @@ const PMCID_PROPERTY = "P932"
 const PUBLICATION_PROPERTY = "P1433"
 const PUBLICATION_DATE_PROPERTY = "P577"
 const TITLE_PROPERTY = "P1476"
+const VOLUME_PROPERTY = "P478"
+const ISSUE_PROPERTY = "P433"
+const PAGES_PROPERTY = "P304"
 const RETRACTED_BY_PROPERTY = "P5824"
+const DOI_PROPERTY = "P356"
+const CITES_WORK_PROPERTY = "P2860"
+const ERRATUM_PROPERTY = "P2507"
+const AUTHOR_PROPERTY = "P50"
+const AUTHOR_NAME_STRING_PROPERTY = "P2093"
+const ORCID_PROPERTY = "P496"
+const SPONSOR_PROPERTY = "P859"
+
+// These properties are used as qualifiers
+const SERIES_ORDINAL_QUALIFIER = "P1545"
+const OBJECT_NAMED_AS_QUALIFIER = "P1932"
+const SUBJECT_NAMED_AS_QUALIFIER = "P1810"
+
+// "grant number" as used on sponsor and funder statements, see https://www.wikidata.org/wiki/Property:P11146
+const GRANT_NUMBER_QUALIFIER = "P11146"
 
 const OFFICIAL_WEBSITE_SOURCE = "S856"
 const STATED_IN_SOURCE = "S248"
@@ -43,7 +80,6 @@ const RETRIEVED_AT_DATE_SOURCE = "S813"
 const PM_ITEM = "Q180686"
 const PMC_ITEM = "Q229883"
 const EuroPMC_ITEM = "Q5412157"
-const REVIEW_ARTICLE_ITEM = "Q7318358"
 
 var CC_LICENSE_ITEM_IDS = map[string]string{
 	"CC0":         "Q6938433",